@@ -0,0 +1,96 @@
+package audio
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// FSStore хранит объекты в каталоге root/ab/cd/<sha256>
+type FSStore struct {
+	root string
+}
+
+func NewFSStore(root string) (*FSStore, error) {
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, err
+	}
+	return &FSStore{root: root}, nil
+}
+
+func (s *FSStore) path(id string) string {
+	return filepath.Join(s.root, id[:2], id[2:4], id)
+}
+
+func (s *FSStore) Put(_ context.Context, data []byte) (string, error) {
+	id := ID(data)
+	dst := s.path(id)
+	if _, err := os.Stat(dst); err == nil {
+		return id, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return "", err
+	}
+	// пишем во временный файл и переименовываем, чтобы читатели не увидели недописанный объект
+	tmp, err := os.CreateTemp(filepath.Dir(dst), ".tmp-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), dst); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+func (s *FSStore) Open(_ context.Context, id string) (*Object, error) {
+	if !ValidID(id) {
+		return nil, ErrInvalidID
+	}
+	f, err := os.Open(s.path(id))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	st, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &Object{ReadSeekCloser: f, ID: id, Size: st.Size(), ModTime: st.ModTime()}, nil
+}
+
+func (s *FSStore) Exists(_ context.Context, id string) (bool, error) {
+	if !ValidID(id) {
+		return false, ErrInvalidID
+	}
+	_, err := os.Stat(s.path(id))
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (s *FSStore) Delete(_ context.Context, id string) error {
+	if !ValidID(id) {
+		return ErrInvalidID
+	}
+	err := os.Remove(s.path(id))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
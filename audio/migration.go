@@ -0,0 +1,129 @@
+package audio
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"bd_back_for_translate_app/database"
+
+	"gorm.io/gorm"
+)
+
+// blobTables — таблицы, в которых аудио раньше лежало bytea-колонкой audio
+var blobTables = []struct{ table, owner string }{
+	{"word_translations", "word_id"},
+	{"text_translations", "text_id"},
+}
+
+// blobBatch — сколько клипов держим в памяти за один проход
+const blobBatch = 100
+
+type blobRow struct {
+	Owner   int
+	Lang    string
+	Audio   []byte
+	AudioID string
+}
+
+func init() {
+	database.Register(database.Migration{
+		Version:  3,
+		Name:     "audio_store",
+		UpFunc:   moveBlobsToStore,
+		DownFunc: moveBlobsToRows,
+	})
+}
+
+// moveBlobsToStore переносит клипы из bytea в хранилище и оставляет в строках только audio_id
+func moveBlobsToStore(tx *gorm.DB) error {
+	if Storage == nil {
+		return errors.New("audio store is not initialized")
+	}
+	ctx := context.Background()
+
+	for _, t := range blobTables {
+		if err := tx.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN audio_id TEXT", t.table)).Error; err != nil {
+			return err
+		}
+
+		moved := 0
+		for {
+			var rows []blobRow
+			if err := tx.Raw(fmt.Sprintf(
+				`SELECT %s AS owner, lang, audio FROM %s
+				 WHERE audio IS NOT NULL AND length(audio) > 0 AND audio_id IS NULL
+				 ORDER BY %[1]s, lang LIMIT ?`, t.owner, t.table), blobBatch).
+				Scan(&rows).Error; err != nil {
+				return err
+			}
+			if len(rows) == 0 {
+				break
+			}
+			for _, r := range rows {
+				id, err := Storage.Put(ctx, r.Audio)
+				if err != nil {
+					return fmt.Errorf("%s %d/%s: %w", t.table, r.Owner, r.Lang, err)
+				}
+				if err := tx.Exec(fmt.Sprintf("UPDATE %s SET audio_id = ? WHERE %s = ? AND lang = ?", t.table, t.owner),
+					id, r.Owner, r.Lang).Error; err != nil {
+					return err
+				}
+			}
+			moved += len(rows)
+		}
+		log.Printf("[audio] %s: moved %d clips to store", t.table, moved)
+
+		if err := tx.Exec(fmt.Sprintf("ALTER TABLE %s DROP COLUMN audio", t.table)).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec(fmt.Sprintf("CREATE INDEX idx_%[1]s_audio_id ON %[1]s (audio_id)", t.table)).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// moveBlobsToRows возвращает клипы в bytea; объекты в хранилище не удаляются
+func moveBlobsToRows(tx *gorm.DB) error {
+	if Storage == nil {
+		return errors.New("audio store is not initialized")
+	}
+	ctx := context.Background()
+
+	for _, t := range blobTables {
+		if err := tx.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN audio BYTEA", t.table)).Error; err != nil {
+			return err
+		}
+
+		for {
+			var rows []blobRow
+			if err := tx.Raw(fmt.Sprintf(
+				`SELECT %s AS owner, lang, audio_id FROM %s
+				 WHERE audio_id IS NOT NULL AND audio IS NULL
+				 ORDER BY %[1]s, lang LIMIT ?`, t.owner, t.table), blobBatch).
+				Scan(&rows).Error; err != nil {
+				return err
+			}
+			if len(rows) == 0 {
+				break
+			}
+			for _, r := range rows {
+				data, err := ReadAll(ctx, Storage, r.AudioID)
+				if err != nil {
+					return fmt.Errorf("%s %d/%s: %w", t.table, r.Owner, r.Lang, err)
+				}
+				if err := tx.Exec(fmt.Sprintf("UPDATE %s SET audio = ? WHERE %s = ? AND lang = ?", t.table, t.owner),
+					data, r.Owner, r.Lang).Error; err != nil {
+					return err
+				}
+			}
+		}
+
+		if err := tx.Exec(fmt.Sprintf("ALTER TABLE %s DROP COLUMN audio_id", t.table)).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package audio
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// ErrNotFound — в хранилище нет объекта с таким ключом
+var ErrNotFound = errors.New("audio: object not found")
+
+// ErrInvalidID — ключ не похож на SHA-256 в hex
+var ErrInvalidID = errors.New("audio: invalid id")
+
+var idRe = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// Object — открытый для чтения аудиофайл; Seek нужен для HTTP Range
+type Object struct {
+	io.ReadSeekCloser
+	ID      string
+	Size    int64
+	ModTime time.Time
+}
+
+// Store — content-addressed хранилище аудио: ключ объекта — SHA-256 его содержимого,
+// поэтому одинаковые клипы хранятся один раз
+type Store interface {
+	// Put сохраняет данные и возвращает их ключ; повторный Put тех же байт ничего не пишет
+	Put(ctx context.Context, data []byte) (string, error)
+	Open(ctx context.Context, id string) (*Object, error)
+	Exists(ctx context.Context, id string) (bool, error)
+	Delete(ctx context.Context, id string) error
+}
+
+// Storage — хранилище, настроенное через окружение в Init
+var Storage Store
+
+// ID вычисляет ключ содержимого
+func ID(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func ValidID(id string) bool {
+	return idRe.MatchString(id)
+}
+
+// Init выбирает бэкенд по AUDIO_STORE: fs (по умолчанию) или s3
+func Init() {
+	var err error
+	switch backend := os.Getenv("AUDIO_STORE"); backend {
+	case "", "fs":
+		dir := os.Getenv("AUDIO_DIR")
+		if dir == "" {
+			dir = "./data/audio"
+		}
+		Storage, err = NewFSStore(dir)
+		if err == nil {
+			log.Printf("Audio store: filesystem %s", dir)
+		}
+
+	case "s3":
+		useSSL, _ := strconv.ParseBool(os.Getenv("S3_USE_SSL"))
+		cfg := S3Config{
+			Endpoint:  os.Getenv("S3_ENDPOINT"),
+			AccessKey: os.Getenv("S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("S3_SECRET_KEY"),
+			Bucket:    os.Getenv("S3_BUCKET"),
+			Prefix:    os.Getenv("S3_PREFIX"),
+			UseSSL:    useSSL,
+		}
+		Storage, err = NewS3Store(context.Background(), cfg)
+		if err == nil {
+			log.Printf("Audio store: s3 %s/%s", cfg.Endpoint, cfg.Bucket)
+		}
+
+	default:
+		log.Fatalf("Unknown AUDIO_STORE %q (expected fs or s3)", backend)
+	}
+	if err != nil {
+		log.Fatalf("Failed to init audio store: %v", err)
+	}
+}
+
+// ReadAll загружает объект целиком
+func ReadAll(ctx context.Context, s Store, id string) ([]byte, error) {
+	obj, err := s.Open(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	defer obj.Close()
+	return io.ReadAll(obj)
+}
+
+// ContentType определяет MIME-тип клипа по сигнатуре файла
+func ContentType(data []byte) string {
+	switch {
+	case len(data) >= 12 && string(data[:4]) == "RIFF" && string(data[8:12]) == "WAVE":
+		return "audio/wav"
+	case bytes.HasPrefix(data, []byte("OggS")):
+		return "audio/ogg"
+	case bytes.HasPrefix(data, []byte("fLaC")):
+		return "audio/flac"
+	case bytes.HasPrefix(data, []byte("ID3")), len(data) >= 2 && data[0] == 0xFF && data[1]&0xE0 == 0xE0:
+		return "audio/mpeg"
+	}
+	return "application/octet-stream"
+}
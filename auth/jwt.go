@@ -0,0 +1,129 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Роли пользователей
+const (
+	RoleAdmin   = "admin"
+	RoleEditor  = "editor"
+	RoleLearner = "learner"
+)
+
+var Roles = []string{RoleAdmin, RoleEditor, RoleLearner}
+
+// Типы токенов: access предъявляется с каждым запросом, refresh — только для получения новой пары
+const (
+	AccessToken  = "access"
+	RefreshToken = "refresh"
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrExpiredToken = errors.New("token expired")
+)
+
+var (
+	secret     []byte
+	AccessTTL  = 15 * time.Minute
+	RefreshTTL = 30 * 24 * time.Hour
+)
+
+// Claims — полезная нагрузка JWT. Subject — id пользователя, ID (jti) — уникальный id токена
+type Claims struct {
+	Subject   string `json:"sub"`
+	Email     string `json:"email,omitempty"`
+	Role      string `json:"role,omitempty"`
+	Type      string `json:"typ"`
+	ID        string `json:"jti"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// UserID — id пользователя из sub
+func (c *Claims) UserID() int {
+	id, _ := strconv.Atoi(c.Subject)
+	return id
+}
+
+// jwtHeader — единственный поддерживаемый заголовок; токены с другим alg отклоняются
+var jwtHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// Init читает ключ подписи JWT_SECRET (не короче 32 байт) и сроки жизни токенов
+func Init() {
+	secret = []byte(os.Getenv("JWT_SECRET"))
+	if len(secret) < 32 {
+		log.Fatalf("JWT_SECRET must be set to at least 32 bytes")
+	}
+	for name, ttl := range map[string]*time.Duration{"ACCESS_TOKEN_TTL": &AccessTTL, "REFRESH_TOKEN_TTL": &RefreshTTL} {
+		v := os.Getenv(name)
+		if v == "" {
+			continue
+		}
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			log.Fatalf("%s must be a positive duration, got %q", name, v)
+		}
+		*ttl = d
+	}
+}
+
+// Issue подписывает токен типа c.Type со сроком ttl; iat, exp и jti заполняются здесь
+func Issue(c Claims, ttl time.Duration) (string, Claims, error) {
+	now := time.Now()
+	c.IssuedAt = now.Unix()
+	c.ExpiresAt = now.Add(ttl).Unix()
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", c, err
+	}
+	c.ID = hex.EncodeToString(id)
+
+	payload, err := json.Marshal(c)
+	if err != nil {
+		return "", c, err
+	}
+	unsigned := jwtHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return unsigned + "." + sign(unsigned), c, nil
+}
+
+// Verify проверяет подпись, срок и тип токена
+func Verify(token, typ string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != jwtHeader {
+		return nil, ErrInvalidToken
+	}
+	if !hmac.Equal([]byte(parts[2]), []byte(sign(parts[0]+"."+parts[1]))) {
+		return nil, ErrInvalidToken
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	var c Claims
+	if err := json.Unmarshal(payload, &c); err != nil || c.Type != typ || c.Subject == "" {
+		return nil, ErrInvalidToken
+	}
+	if time.Now().Unix() >= c.ExpiresAt {
+		return nil, ErrExpiredToken
+	}
+	return &c, nil
+}
+
+func sign(s string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(s))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
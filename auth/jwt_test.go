@@ -0,0 +1,112 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func init() {
+	secret = []byte("test-secret-test-secret-test-secret!")
+}
+
+func issue(t *testing.T, typ string, ttl time.Duration) (string, Claims) {
+	t.Helper()
+	token, claims, err := Issue(Claims{Subject: "42", Email: "a@example.com", Role: RoleEditor, Type: typ}, ttl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token, claims
+}
+
+func TestRoundTrip(t *testing.T) {
+	token, issued := issue(t, AccessToken, time.Minute)
+	got, err := Verify(token, AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *got != issued {
+		t.Errorf("claims %+v, want %+v", *got, issued)
+	}
+	if got.UserID() != 42 || got.ID == "" || got.ExpiresAt-got.IssuedAt != 60 {
+		t.Errorf("unexpected claims %+v", *got)
+	}
+}
+
+func TestTamperedToken(t *testing.T) {
+	token, claims := issue(t, AccessToken, time.Minute)
+	parts := strings.Split(token, ".")
+
+	claims.Role = RoleAdmin
+	payload, _ := json.Marshal(claims)
+	forged := parts[0] + "." + base64.RawURLEncoding.EncodeToString(payload) + "." + parts[2]
+	if _, err := Verify(forged, AccessToken); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("forged payload: err = %v", err)
+	}
+
+	sig := []byte(parts[2])
+	sig[0] ^= 1
+	if _, err := Verify(parts[0]+"."+parts[1]+"."+string(sig), AccessToken); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("tampered signature: err = %v", err)
+	}
+
+	for _, bad := range []string{"", token + ".x", parts[0] + "." + parts[1]} {
+		if _, err := Verify(bad, AccessToken); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("Verify(%q): err = %v", bad, err)
+		}
+	}
+}
+
+func TestOtherAlgorithm(t *testing.T) {
+	token, _ := issue(t, AccessToken, time.Minute)
+	payload := strings.Split(token, ".")[1]
+	header := func(alg string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"` + alg + `","typ":"JWT"}`))
+	}
+
+	if _, err := Verify(header("none")+"."+payload+".", AccessToken); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("alg none: err = %v", err)
+	}
+
+	// подпись верная для HS512 тем же ключом, но такой alg не принимается
+	unsigned := header("HS512") + "." + payload
+	mac := hmac.New(sha512.New, secret)
+	mac.Write([]byte(unsigned))
+	hs512 := unsigned + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+	if _, err := Verify(hs512, AccessToken); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("alg HS512: err = %v", err)
+	}
+}
+
+func TestExpiredToken(t *testing.T) {
+	token, _ := issue(t, AccessToken, -time.Second)
+	if _, err := Verify(token, AccessToken); !errors.Is(err, ErrExpiredToken) {
+		t.Errorf("err = %v, want ErrExpiredToken", err)
+	}
+}
+
+func TestWrongType(t *testing.T) {
+	refresh, _ := issue(t, RefreshToken, time.Hour)
+	if _, err := Verify(refresh, AccessToken); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("refresh as access: err = %v", err)
+	}
+	access, _ := issue(t, AccessToken, time.Hour)
+	if _, err := Verify(access, RefreshToken); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("access as refresh: err = %v", err)
+	}
+}
+
+func TestOtherSecret(t *testing.T) {
+	token, _ := issue(t, AccessToken, time.Minute)
+	saved := secret
+	secret = []byte("another-secret-another-secret-another")
+	defer func() { secret = saved }()
+	if _, err := Verify(token, AccessToken); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("err = %v", err)
+	}
+}
@@ -0,0 +1,27 @@
+package auth
+
+import "golang.org/x/crypto/bcrypt"
+
+// Ограничения длины пароля в байтах: bcrypt учитывает только первые 72
+const (
+	MinPasswordLen = 8
+	MaxPasswordLen = 72
+)
+
+// dummyHash сравнивается при входе с неизвестным email, чтобы время ответа не выдавало,
+// есть ли такой пользователь
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("dummy password"), bcrypt.DefaultCost)
+
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	return string(hash), err
+}
+
+// CheckPassword сверяет пароль с хэшем; пустой хэш — пользователя нет
+func CheckPassword(hash, password string) bool {
+	if hash == "" {
+		bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+		return false
+	}
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
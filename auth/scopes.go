@@ -0,0 +1,46 @@
+package auth
+
+import (
+	"slices"
+	"strings"
+)
+
+// Права доступа. Пользователь получает их по роли, API-ключ — те, что указаны при выпуске
+const (
+	ScopeRead            = "read"
+	ScopeCategoriesWrite = "categories:write"
+	ScopeWordsWrite      = "words:write"
+	ScopeTextsWrite      = "texts:write"
+	ScopeGrammarsWrite   = "grammars:write"
+	ScopeTrashWrite      = "trash:write"
+	ScopeAudioRegenerate = "audio:regenerate"
+	// ScopeAdmin — управление пользователями и ключами; ключу не выдаётся
+	ScopeAdmin = "admin"
+)
+
+// Scopes — права, которые можно выдать API-ключу
+var Scopes = []string{
+	ScopeRead,
+	ScopeCategoriesWrite,
+	ScopeWordsWrite,
+	ScopeTextsWrite,
+	ScopeGrammarsWrite,
+	ScopeTrashWrite,
+	ScopeAudioRegenerate,
+}
+
+var roleScopes = map[string][]string{
+	RoleLearner: {ScopeRead},
+	RoleEditor:  Scopes,
+	RoleAdmin:   append(slices.Clone(Scopes), ScopeAdmin),
+}
+
+// RoleAllows — даёт ли роль право scope
+func RoleAllows(role, scope string) bool {
+	return slices.Contains(roleScopes[role], scope)
+}
+
+// Editorial — право на изменение содержимого: с ним видны и неопубликованные записи
+func Editorial(scope string) bool {
+	return strings.HasSuffix(scope, ":write")
+}
@@ -0,0 +1,51 @@
+package auth
+
+import "testing"
+
+func TestRoleAllows(t *testing.T) {
+	cases := []struct {
+		role, scope string
+		want        bool
+	}{
+		{RoleLearner, ScopeRead, true},
+		{RoleLearner, ScopeWordsWrite, false},
+		{RoleLearner, ScopeAudioRegenerate, false},
+		{RoleLearner, ScopeAdmin, false},
+		{RoleEditor, ScopeRead, true},
+		{RoleEditor, ScopeWordsWrite, true},
+		{RoleEditor, ScopeTrashWrite, true},
+		{RoleEditor, ScopeAudioRegenerate, true},
+		{RoleEditor, ScopeAdmin, false},
+		{RoleAdmin, ScopeGrammarsWrite, true},
+		{RoleAdmin, ScopeAdmin, true},
+		{"", ScopeRead, false},
+		{"root", ScopeAdmin, false},
+		{RoleAdmin, "unknown", false},
+	}
+	for _, tc := range cases {
+		if got := RoleAllows(tc.role, tc.scope); got != tc.want {
+			t.Errorf("RoleAllows(%q, %q) = %v, want %v", tc.role, tc.scope, got, tc.want)
+		}
+	}
+}
+
+func TestAdminScopeNotIssuable(t *testing.T) {
+	for _, s := range Scopes {
+		if s == ScopeAdmin {
+			t.Fatal("admin scope must not be issuable to API keys")
+		}
+	}
+	// права администратора собираются из копии Scopes и не должны менять права редактора
+	if len(roleScopes[RoleEditor]) != len(Scopes) {
+		t.Error("editor scopes changed when building admin scopes")
+	}
+}
+
+func TestEditorial(t *testing.T) {
+	for _, s := range Scopes {
+		want := s != ScopeRead && s != ScopeAudioRegenerate
+		if Editorial(s) != want {
+			t.Errorf("Editorial(%q) = %v", s, !want)
+		}
+	}
+}
@@ -0,0 +1,352 @@
+package database
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+//go:embed migrations/*.sql
+var migrationFS embed.FS
+
+// migrationLockID — ключ pg_advisory_lock, чтобы два процесса не мигрировали одновременно
+const migrationLockID = 7305418261
+
+// Migration — одна версия схемы: пара файлов NNNN_name.up.sql / NNNN_name.down.sql
+// или миграция на Go (UpFunc/DownFunc), зарегистрированная через Register
+type Migration struct {
+	Version  int64
+	Name     string
+	Up       string
+	Down     string
+	UpFunc   func(tx *gorm.DB) error
+	DownFunc func(tx *gorm.DB) error
+}
+
+// registered — Go-миграции для шагов, которые нельзя выразить на SQL
+// (например, перенос данных во внешнее хранилище)
+var registered []Migration
+
+// Register добавляет Go-миграцию; вызывается из init() пакетов-владельцев данных
+func Register(m Migration) {
+	registered = append(registered, m)
+}
+
+// MigrationStatus — строка вывода `migrate status`
+type MigrationStatus struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+type schemaMigration struct {
+	Version   int64     `gorm:"primaryKey;column:version"`
+	Name      string    `gorm:"column:name"`
+	AppliedAt time.Time `gorm:"column:applied_at"`
+}
+
+func (schemaMigration) TableName() string { return "schema_migrations" }
+
+// LoadMigrations читает встроенные в бинарник миграции и сортирует их по версии
+func LoadMigrations() ([]Migration, error) {
+	files, err := fs.Glob(migrationFS, "migrations/*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int64]*Migration{}
+	for _, path := range files {
+		base := strings.TrimPrefix(path, "migrations/")
+
+		var direction string
+		switch {
+		case strings.HasSuffix(base, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(base, ".down.sql"):
+			direction = "down"
+		default:
+			return nil, fmt.Errorf("migration %s: expected .up.sql or .down.sql suffix", base)
+		}
+		stem := strings.TrimSuffix(base, "."+direction+".sql")
+
+		prefix, name, ok := strings.Cut(stem, "_")
+		if !ok {
+			return nil, fmt.Errorf("migration %s: expected NNNN_name prefix", base)
+		}
+		version, err := strconv.ParseInt(prefix, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: invalid version: %w", base, err)
+		}
+
+		body, err := migrationFS.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: name}
+			byVersion[version] = m
+		} else if m.Name != name {
+			return nil, fmt.Errorf("migration %d: conflicting names %q and %q", version, m.Name, name)
+		}
+		if direction == "up" {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	for _, r := range registered {
+		if _, dup := byVersion[r.Version]; dup {
+			return nil, fmt.Errorf("migration %d: registered twice", r.Version)
+		}
+		if r.UpFunc == nil {
+			return nil, fmt.Errorf("migration %d_%s: missing UpFunc", r.Version, r.Name)
+		}
+		m := r
+		byVersion[r.Version] = &m
+	}
+
+	list := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" && m.UpFunc == nil {
+			return nil, fmt.Errorf("migration %d_%s: missing up file", m.Version, m.Name)
+		}
+		list = append(list, *m)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Version < list[j].Version })
+	return list, nil
+}
+
+func (m Migration) apply(tx *gorm.DB, sql string, fn func(tx *gorm.DB) error) error {
+	if fn != nil {
+		return fn(tx)
+	}
+	return tx.Exec(sql).Error
+}
+
+func ensureMigrationsTable(db *gorm.DB) error {
+	return db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    BIGINT PRIMARY KEY,
+		name       TEXT NOT NULL,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`).Error
+}
+
+func appliedMigrations(db *gorm.DB) (map[int64]schemaMigration, error) {
+	var rows []schemaMigration
+	if err := db.Order("version").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	applied := make(map[int64]schemaMigration, len(rows))
+	for _, r := range rows {
+		applied[r.Version] = r
+	}
+	return applied, nil
+}
+
+// withMigrationLock выполняет fn на одном соединении под advisory lock
+func withMigrationLock(db *gorm.DB, fn func(conn *gorm.DB) error) error {
+	return db.Connection(func(conn *gorm.DB) error {
+		if err := conn.Exec("SELECT pg_advisory_lock(?)", migrationLockID).Error; err != nil {
+			return err
+		}
+		defer conn.Exec("SELECT pg_advisory_unlock(?)", migrationLockID)
+
+		if err := ensureMigrationsTable(conn); err != nil {
+			return err
+		}
+		return fn(conn)
+	})
+}
+
+// MigrateUp применяет до limit ожидающих миграций (limit <= 0 — все)
+func MigrateUp(db *gorm.DB, limit int) ([]Migration, error) {
+	migrations, err := LoadMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	err = withMigrationLock(db, func(conn *gorm.DB) error {
+		applied, err := appliedMigrations(conn)
+		if err != nil {
+			return err
+		}
+		for _, m := range migrations {
+			if _, ok := applied[m.Version]; ok {
+				continue
+			}
+			if limit > 0 && len(done) >= limit {
+				break
+			}
+			err := conn.Transaction(func(tx *gorm.DB) error {
+				if err := m.apply(tx, m.Up, m.UpFunc); err != nil {
+					return err
+				}
+				return tx.Create(&schemaMigration{Version: m.Version, Name: m.Name, AppliedAt: time.Now()}).Error
+			})
+			if err != nil {
+				return fmt.Errorf("migration %d_%s up: %w", m.Version, m.Name, err)
+			}
+			done = append(done, m)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// MigrateDown откатывает steps последних применённых миграций
+func MigrateDown(db *gorm.DB, steps int) ([]Migration, error) {
+	migrations, err := LoadMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	err = withMigrationLock(db, func(conn *gorm.DB) error {
+		applied, err := appliedMigrations(conn)
+		if err != nil {
+			return err
+		}
+		for i := len(migrations) - 1; i >= 0 && len(done) < steps; i-- {
+			m := migrations[i]
+			if _, ok := applied[m.Version]; !ok {
+				continue
+			}
+			if m.Down == "" && m.DownFunc == nil {
+				return fmt.Errorf("migration %d_%s is irreversible: no down step", m.Version, m.Name)
+			}
+			err := conn.Transaction(func(tx *gorm.DB) error {
+				if err := m.apply(tx, m.Down, m.DownFunc); err != nil {
+					return err
+				}
+				return tx.Delete(&schemaMigration{}, m.Version).Error
+			})
+			if err != nil {
+				return fmt.Errorf("migration %d_%s down: %w", m.Version, m.Name, err)
+			}
+			done = append(done, m)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// MigrationStatuses возвращает все известные миграции с отметкой о применении
+func MigrationStatuses(db *gorm.DB) ([]MigrationStatus, error) {
+	migrations, err := LoadMigrations()
+	if err != nil {
+		return nil, err
+	}
+	if err := ensureMigrationsTable(db); err != nil {
+		return nil, err
+	}
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]MigrationStatus, 0, len(migrations))
+	for _, m := range migrations {
+		st := MigrationStatus{Migration: m}
+		if a, ok := applied[m.Version]; ok {
+			t := a.AppliedAt
+			st.AppliedAt = &t
+		}
+		list = append(list, st)
+	}
+	return list, nil
+}
+
+// PendingMigrations — число ещё не применённых миграций
+func PendingMigrations(db *gorm.DB) (int, error) {
+	list, err := MigrationStatuses(db)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, st := range list {
+		if st.AppliedAt == nil {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// RunMigrateCommand обрабатывает `migrate up|down|status|redo [n]`
+func RunMigrateCommand(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: migrate up [n] | down [n] | status | redo")
+	}
+
+	n := 0
+	if len(args) > 1 {
+		v, err := strconv.Atoi(args[1])
+		if err != nil || v < 1 {
+			return fmt.Errorf("invalid step count %q", args[1])
+		}
+		n = v
+	}
+
+	report := func(verb string, list []Migration) {
+		if len(list) == 0 {
+			fmt.Printf("nothing to %s\n", verb)
+		}
+		for _, m := range list {
+			fmt.Printf("%s %04d_%s\n", verb, m.Version, m.Name)
+		}
+	}
+
+	switch args[0] {
+	case "up":
+		done, err := MigrateUp(DB, n)
+		report("up", done)
+		return err
+
+	case "down":
+		if n == 0 {
+			n = 1
+		}
+		done, err := MigrateDown(DB, n)
+		report("down", done)
+		return err
+
+	case "redo":
+		down, err := MigrateDown(DB, 1)
+		report("down", down)
+		if err != nil || len(down) == 0 {
+			return err
+		}
+		up, err := MigrateUp(DB, 1)
+		report("up", up)
+		return err
+
+	case "status":
+		list, err := MigrationStatuses(DB)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, st := range list {
+			applied := "pending"
+			if st.AppliedAt != nil {
+				applied = st.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\n", st.Version, st.Name, applied)
+		}
+		return w.Flush()
+
+	default:
+		return fmt.Errorf("unknown migrate command %q", args[0])
+	}
+}
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS grammar_exceptions;
+DROP TABLE IF EXISTS grammar_examples;
+DROP TABLE IF EXISTS grammar_rules;
+DROP TABLE IF EXISTS grammars;
+DROP TABLE IF EXISTS texts;
+DROP TABLE IF EXISTS words;
+DROP TABLE IF EXISTS categories;
@@ -0,0 +1,92 @@
+-- Базовая схема: фиксирует таблицы, которые раньше создавались вручную.
+-- IF NOT EXISTS позволяет принять уже существующие базы без пересоздания.
+
+CREATE TABLE IF NOT EXISTS categories (
+    id           SERIAL PRIMARY KEY,
+    name_en      TEXT NOT NULL DEFAULT '',
+    name_ru      TEXT NOT NULL DEFAULT '',
+    name_de      TEXT NOT NULL DEFAULT '',
+    type_name    TEXT NOT NULL DEFAULT '',
+    type_name_ru TEXT NOT NULL DEFAULT '',
+    type_name_de TEXT NOT NULL DEFAULT '',
+    entity       TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS words (
+    id               SERIAL PRIMARY KEY,
+    word_ru          TEXT NOT NULL DEFAULT '',
+    word_en          TEXT NOT NULL DEFAULT '',
+    word_de          TEXT NOT NULL DEFAULT '',
+    transcription_ru TEXT NOT NULL DEFAULT '',
+    transcription_en TEXT NOT NULL DEFAULT '',
+    transcription_de TEXT NOT NULL DEFAULT '',
+    audio_ru         BYTEA,
+    audio_en         BYTEA,
+    audio_de         BYTEA,
+    category_id      INTEGER NOT NULL DEFAULT 0,
+    type_ru          TEXT NOT NULL DEFAULT '',
+    type_en          TEXT NOT NULL DEFAULT '',
+    type_de          TEXT NOT NULL DEFAULT '',
+    status           TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS texts (
+    id               SERIAL PRIMARY KEY,
+    title_ru         TEXT NOT NULL DEFAULT '',
+    title_en         TEXT NOT NULL DEFAULT '',
+    title_de         TEXT NOT NULL DEFAULT '',
+    content_ru       TEXT NOT NULL DEFAULT '',
+    content_en       TEXT NOT NULL DEFAULT '',
+    content_de       TEXT NOT NULL DEFAULT '',
+    transcription_ru TEXT NOT NULL DEFAULT '',
+    transcription_en TEXT NOT NULL DEFAULT '',
+    transcription_de TEXT NOT NULL DEFAULT '',
+    audio_ru         BYTEA,
+    audio_en         BYTEA,
+    audio_de         BYTEA,
+    category_id      INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS grammars (
+    id             SERIAL PRIMARY KEY,
+    title_ru       TEXT NOT NULL DEFAULT '',
+    title_en       TEXT NOT NULL DEFAULT '',
+    title_de       TEXT NOT NULL DEFAULT '',
+    description_ru TEXT NOT NULL DEFAULT '',
+    description_en TEXT NOT NULL DEFAULT '',
+    description_de TEXT NOT NULL DEFAULT '',
+    language       TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS grammar_rules (
+    id                  SERIAL PRIMARY KEY,
+    grammar_id          INTEGER NOT NULL DEFAULT 0,
+    rule_name_ru        TEXT NOT NULL DEFAULT '',
+    rule_name_en        TEXT NOT NULL DEFAULT '',
+    rule_name_de        TEXT NOT NULL DEFAULT '',
+    rule_description_ru TEXT NOT NULL DEFAULT '',
+    rule_description_en TEXT NOT NULL DEFAULT '',
+    rule_description_de TEXT NOT NULL DEFAULT ''
+);
+CREATE INDEX IF NOT EXISTS idx_grammar_rules_grammar_id ON grammar_rules (grammar_id);
+
+CREATE TABLE IF NOT EXISTS grammar_examples (
+    id         SERIAL PRIMARY KEY,
+    rule_id    INTEGER NOT NULL DEFAULT 0,
+    example_ru TEXT NOT NULL DEFAULT '',
+    example_en TEXT NOT NULL DEFAULT '',
+    example_de TEXT NOT NULL DEFAULT ''
+);
+CREATE INDEX IF NOT EXISTS idx_grammar_examples_rule_id ON grammar_examples (rule_id);
+
+CREATE TABLE IF NOT EXISTS grammar_exceptions (
+    id             SERIAL PRIMARY KEY,
+    rule_id        INTEGER NOT NULL DEFAULT 0,
+    description_ru TEXT NOT NULL DEFAULT '',
+    description_en TEXT NOT NULL DEFAULT '',
+    description_de TEXT NOT NULL DEFAULT '',
+    explanation_ru TEXT NOT NULL DEFAULT '',
+    explanation_en TEXT NOT NULL DEFAULT '',
+    explanation_de TEXT NOT NULL DEFAULT ''
+);
+CREATE INDEX IF NOT EXISTS idx_grammar_exceptions_rule_id ON grammar_exceptions (rule_id);
@@ -0,0 +1,115 @@
+-- Возврат к колонкам на язык; переводы на языки кроме ru/en/de теряются.
+
+ALTER TABLE categories
+    ADD COLUMN name_en      TEXT NOT NULL DEFAULT '',
+    ADD COLUMN name_ru      TEXT NOT NULL DEFAULT '',
+    ADD COLUMN name_de      TEXT NOT NULL DEFAULT '',
+    ADD COLUMN type_name    TEXT NOT NULL DEFAULT '',
+    ADD COLUMN type_name_ru TEXT NOT NULL DEFAULT '',
+    ADD COLUMN type_name_de TEXT NOT NULL DEFAULT '';
+UPDATE categories c SET name_ru = t.name, type_name_ru = t.type_name
+FROM category_translations t WHERE t.category_id = c.id AND t.lang = 'ru';
+UPDATE categories c SET name_en = t.name, type_name = t.type_name
+FROM category_translations t WHERE t.category_id = c.id AND t.lang = 'en';
+UPDATE categories c SET name_de = t.name, type_name_de = t.type_name
+FROM category_translations t WHERE t.category_id = c.id AND t.lang = 'de';
+DROP TABLE category_translations;
+
+ALTER TABLE words
+    ADD COLUMN word_ru          TEXT NOT NULL DEFAULT '',
+    ADD COLUMN word_en          TEXT NOT NULL DEFAULT '',
+    ADD COLUMN word_de          TEXT NOT NULL DEFAULT '',
+    ADD COLUMN transcription_ru TEXT NOT NULL DEFAULT '',
+    ADD COLUMN transcription_en TEXT NOT NULL DEFAULT '',
+    ADD COLUMN transcription_de TEXT NOT NULL DEFAULT '',
+    ADD COLUMN audio_ru         BYTEA,
+    ADD COLUMN audio_en         BYTEA,
+    ADD COLUMN audio_de         BYTEA,
+    ADD COLUMN type_ru          TEXT NOT NULL DEFAULT '',
+    ADD COLUMN type_en          TEXT NOT NULL DEFAULT '',
+    ADD COLUMN type_de          TEXT NOT NULL DEFAULT '';
+UPDATE words w SET word_ru = t.word, transcription_ru = t.transcription, type_ru = t.type, audio_ru = t.audio
+FROM word_translations t WHERE t.word_id = w.id AND t.lang = 'ru';
+UPDATE words w SET word_en = t.word, transcription_en = t.transcription, type_en = t.type, audio_en = t.audio
+FROM word_translations t WHERE t.word_id = w.id AND t.lang = 'en';
+UPDATE words w SET word_de = t.word, transcription_de = t.transcription, type_de = t.type, audio_de = t.audio
+FROM word_translations t WHERE t.word_id = w.id AND t.lang = 'de';
+DROP TABLE word_translations;
+
+ALTER TABLE texts
+    ADD COLUMN title_ru         TEXT NOT NULL DEFAULT '',
+    ADD COLUMN title_en         TEXT NOT NULL DEFAULT '',
+    ADD COLUMN title_de         TEXT NOT NULL DEFAULT '',
+    ADD COLUMN content_ru       TEXT NOT NULL DEFAULT '',
+    ADD COLUMN content_en       TEXT NOT NULL DEFAULT '',
+    ADD COLUMN content_de       TEXT NOT NULL DEFAULT '',
+    ADD COLUMN transcription_ru TEXT NOT NULL DEFAULT '',
+    ADD COLUMN transcription_en TEXT NOT NULL DEFAULT '',
+    ADD COLUMN transcription_de TEXT NOT NULL DEFAULT '',
+    ADD COLUMN audio_ru         BYTEA,
+    ADD COLUMN audio_en         BYTEA,
+    ADD COLUMN audio_de         BYTEA;
+UPDATE texts x SET title_ru = t.title, content_ru = t.content, transcription_ru = t.transcription, audio_ru = t.audio
+FROM text_translations t WHERE t.text_id = x.id AND t.lang = 'ru';
+UPDATE texts x SET title_en = t.title, content_en = t.content, transcription_en = t.transcription, audio_en = t.audio
+FROM text_translations t WHERE t.text_id = x.id AND t.lang = 'en';
+UPDATE texts x SET title_de = t.title, content_de = t.content, transcription_de = t.transcription, audio_de = t.audio
+FROM text_translations t WHERE t.text_id = x.id AND t.lang = 'de';
+DROP TABLE text_translations;
+
+ALTER TABLE grammars
+    ADD COLUMN title_ru       TEXT NOT NULL DEFAULT '',
+    ADD COLUMN title_en       TEXT NOT NULL DEFAULT '',
+    ADD COLUMN title_de       TEXT NOT NULL DEFAULT '',
+    ADD COLUMN description_ru TEXT NOT NULL DEFAULT '',
+    ADD COLUMN description_en TEXT NOT NULL DEFAULT '',
+    ADD COLUMN description_de TEXT NOT NULL DEFAULT '';
+UPDATE grammars g SET title_ru = t.title, description_ru = t.description
+FROM grammar_translations t WHERE t.grammar_id = g.id AND t.lang = 'ru';
+UPDATE grammars g SET title_en = t.title, description_en = t.description
+FROM grammar_translations t WHERE t.grammar_id = g.id AND t.lang = 'en';
+UPDATE grammars g SET title_de = t.title, description_de = t.description
+FROM grammar_translations t WHERE t.grammar_id = g.id AND t.lang = 'de';
+DROP TABLE grammar_translations;
+
+ALTER TABLE grammar_rules
+    ADD COLUMN rule_name_ru        TEXT NOT NULL DEFAULT '',
+    ADD COLUMN rule_name_en        TEXT NOT NULL DEFAULT '',
+    ADD COLUMN rule_name_de        TEXT NOT NULL DEFAULT '',
+    ADD COLUMN rule_description_ru TEXT NOT NULL DEFAULT '',
+    ADD COLUMN rule_description_en TEXT NOT NULL DEFAULT '',
+    ADD COLUMN rule_description_de TEXT NOT NULL DEFAULT '';
+UPDATE grammar_rules r SET rule_name_ru = t.name, rule_description_ru = t.description
+FROM grammar_rule_translations t WHERE t.rule_id = r.id AND t.lang = 'ru';
+UPDATE grammar_rules r SET rule_name_en = t.name, rule_description_en = t.description
+FROM grammar_rule_translations t WHERE t.rule_id = r.id AND t.lang = 'en';
+UPDATE grammar_rules r SET rule_name_de = t.name, rule_description_de = t.description
+FROM grammar_rule_translations t WHERE t.rule_id = r.id AND t.lang = 'de';
+DROP TABLE grammar_rule_translations;
+
+ALTER TABLE grammar_examples
+    ADD COLUMN example_ru TEXT NOT NULL DEFAULT '',
+    ADD COLUMN example_en TEXT NOT NULL DEFAULT '',
+    ADD COLUMN example_de TEXT NOT NULL DEFAULT '';
+UPDATE grammar_examples e SET example_ru = t.example
+FROM grammar_example_translations t WHERE t.example_id = e.id AND t.lang = 'ru';
+UPDATE grammar_examples e SET example_en = t.example
+FROM grammar_example_translations t WHERE t.example_id = e.id AND t.lang = 'en';
+UPDATE grammar_examples e SET example_de = t.example
+FROM grammar_example_translations t WHERE t.example_id = e.id AND t.lang = 'de';
+DROP TABLE grammar_example_translations;
+
+ALTER TABLE grammar_exceptions
+    ADD COLUMN description_ru TEXT NOT NULL DEFAULT '',
+    ADD COLUMN description_en TEXT NOT NULL DEFAULT '',
+    ADD COLUMN description_de TEXT NOT NULL DEFAULT '',
+    ADD COLUMN explanation_ru TEXT NOT NULL DEFAULT '',
+    ADD COLUMN explanation_en TEXT NOT NULL DEFAULT '',
+    ADD COLUMN explanation_de TEXT NOT NULL DEFAULT '';
+UPDATE grammar_exceptions e SET description_ru = t.description, explanation_ru = t.explanation
+FROM grammar_exception_translations t WHERE t.exception_id = e.id AND t.lang = 'ru';
+UPDATE grammar_exceptions e SET description_en = t.description, explanation_en = t.explanation
+FROM grammar_exception_translations t WHERE t.exception_id = e.id AND t.lang = 'en';
+UPDATE grammar_exceptions e SET description_de = t.description, explanation_de = t.explanation
+FROM grammar_exception_translations t WHERE t.exception_id = e.id AND t.lang = 'de';
+DROP TABLE grammar_exception_translations;
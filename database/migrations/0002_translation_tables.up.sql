@@ -0,0 +1,169 @@
+-- Переводы выносятся из колонок вида title_ru/title_en/title_de в таблицы *_translations,
+-- ключ — (id сущности, код языка). Новый язык больше не требует новых колонок.
+
+CREATE TABLE category_translations (
+    category_id INTEGER NOT NULL REFERENCES categories (id) ON DELETE CASCADE,
+    lang        TEXT    NOT NULL,
+    name        TEXT    NOT NULL DEFAULT '',
+    type_name   TEXT    NOT NULL DEFAULT '',
+    PRIMARY KEY (category_id, lang)
+);
+
+INSERT INTO category_translations (category_id, lang, name, type_name)
+SELECT id, lang, coalesce(name, ''), coalesce(type_name, '')
+FROM (
+    SELECT id, 'ru' AS lang, name_ru AS name, type_name_ru AS type_name FROM categories
+    UNION ALL
+    SELECT id, 'en', name_en, type_name FROM categories
+    UNION ALL
+    SELECT id, 'de', name_de, type_name_de FROM categories
+) t
+WHERE coalesce(name, '') <> '' OR coalesce(type_name, '') <> '';
+
+ALTER TABLE categories
+    DROP COLUMN name_ru, DROP COLUMN name_en, DROP COLUMN name_de,
+    DROP COLUMN type_name, DROP COLUMN type_name_ru, DROP COLUMN type_name_de;
+
+CREATE TABLE word_translations (
+    word_id       INTEGER NOT NULL REFERENCES words (id) ON DELETE CASCADE,
+    lang          TEXT    NOT NULL,
+    word          TEXT    NOT NULL DEFAULT '',
+    transcription TEXT    NOT NULL DEFAULT '',
+    type          TEXT    NOT NULL DEFAULT '',
+    audio         BYTEA,
+    PRIMARY KEY (word_id, lang)
+);
+
+INSERT INTO word_translations (word_id, lang, word, transcription, type, audio)
+SELECT id, lang, coalesce(word, ''), coalesce(transcription, ''), coalesce(type, ''), audio
+FROM (
+    SELECT id, 'ru' AS lang, word_ru AS word, transcription_ru AS transcription, type_ru AS type, audio_ru AS audio FROM words
+    UNION ALL
+    SELECT id, 'en', word_en, transcription_en, type_en, audio_en FROM words
+    UNION ALL
+    SELECT id, 'de', word_de, transcription_de, type_de, audio_de FROM words
+) t
+WHERE coalesce(word, '') <> '' OR coalesce(transcription, '') <> '' OR coalesce(type, '') <> '' OR audio IS NOT NULL;
+
+ALTER TABLE words
+    DROP COLUMN word_ru, DROP COLUMN word_en, DROP COLUMN word_de,
+    DROP COLUMN transcription_ru, DROP COLUMN transcription_en, DROP COLUMN transcription_de,
+    DROP COLUMN type_ru, DROP COLUMN type_en, DROP COLUMN type_de,
+    DROP COLUMN audio_ru, DROP COLUMN audio_en, DROP COLUMN audio_de;
+
+CREATE TABLE text_translations (
+    text_id       INTEGER NOT NULL REFERENCES texts (id) ON DELETE CASCADE,
+    lang          TEXT    NOT NULL,
+    title         TEXT    NOT NULL DEFAULT '',
+    content       TEXT    NOT NULL DEFAULT '',
+    transcription TEXT    NOT NULL DEFAULT '',
+    audio         BYTEA,
+    PRIMARY KEY (text_id, lang)
+);
+
+INSERT INTO text_translations (text_id, lang, title, content, transcription, audio)
+SELECT id, lang, coalesce(title, ''), coalesce(content, ''), coalesce(transcription, ''), audio
+FROM (
+    SELECT id, 'ru' AS lang, title_ru AS title, content_ru AS content, transcription_ru AS transcription, audio_ru AS audio FROM texts
+    UNION ALL
+    SELECT id, 'en', title_en, content_en, transcription_en, audio_en FROM texts
+    UNION ALL
+    SELECT id, 'de', title_de, content_de, transcription_de, audio_de FROM texts
+) t
+WHERE coalesce(title, '') <> '' OR coalesce(content, '') <> '' OR coalesce(transcription, '') <> '' OR audio IS NOT NULL;
+
+ALTER TABLE texts
+    DROP COLUMN title_ru, DROP COLUMN title_en, DROP COLUMN title_de,
+    DROP COLUMN content_ru, DROP COLUMN content_en, DROP COLUMN content_de,
+    DROP COLUMN transcription_ru, DROP COLUMN transcription_en, DROP COLUMN transcription_de,
+    DROP COLUMN audio_ru, DROP COLUMN audio_en, DROP COLUMN audio_de;
+
+CREATE TABLE grammar_translations (
+    grammar_id  INTEGER NOT NULL REFERENCES grammars (id) ON DELETE CASCADE,
+    lang        TEXT    NOT NULL,
+    title       TEXT    NOT NULL DEFAULT '',
+    description TEXT    NOT NULL DEFAULT '',
+    PRIMARY KEY (grammar_id, lang)
+);
+
+INSERT INTO grammar_translations (grammar_id, lang, title, description)
+SELECT id, lang, coalesce(title, ''), coalesce(description, '')
+FROM (
+    SELECT id, 'ru' AS lang, title_ru AS title, description_ru AS description FROM grammars
+    UNION ALL
+    SELECT id, 'en', title_en, description_en FROM grammars
+    UNION ALL
+    SELECT id, 'de', title_de, description_de FROM grammars
+) t
+WHERE coalesce(title, '') <> '' OR coalesce(description, '') <> '';
+
+ALTER TABLE grammars
+    DROP COLUMN title_ru, DROP COLUMN title_en, DROP COLUMN title_de,
+    DROP COLUMN description_ru, DROP COLUMN description_en, DROP COLUMN description_de;
+
+CREATE TABLE grammar_rule_translations (
+    rule_id     INTEGER NOT NULL REFERENCES grammar_rules (id) ON DELETE CASCADE,
+    lang        TEXT    NOT NULL,
+    name        TEXT    NOT NULL DEFAULT '',
+    description TEXT    NOT NULL DEFAULT '',
+    PRIMARY KEY (rule_id, lang)
+);
+
+INSERT INTO grammar_rule_translations (rule_id, lang, name, description)
+SELECT id, lang, coalesce(name, ''), coalesce(description, '')
+FROM (
+    SELECT id, 'ru' AS lang, rule_name_ru AS name, rule_description_ru AS description FROM grammar_rules
+    UNION ALL
+    SELECT id, 'en', rule_name_en, rule_description_en FROM grammar_rules
+    UNION ALL
+    SELECT id, 'de', rule_name_de, rule_description_de FROM grammar_rules
+) t
+WHERE coalesce(name, '') <> '' OR coalesce(description, '') <> '';
+
+ALTER TABLE grammar_rules
+    DROP COLUMN rule_name_ru, DROP COLUMN rule_name_en, DROP COLUMN rule_name_de,
+    DROP COLUMN rule_description_ru, DROP COLUMN rule_description_en, DROP COLUMN rule_description_de;
+
+CREATE TABLE grammar_example_translations (
+    example_id INTEGER NOT NULL REFERENCES grammar_examples (id) ON DELETE CASCADE,
+    lang       TEXT    NOT NULL,
+    example    TEXT    NOT NULL DEFAULT '',
+    PRIMARY KEY (example_id, lang)
+);
+
+INSERT INTO grammar_example_translations (example_id, lang, example)
+SELECT id, lang, coalesce(example, '')
+FROM (
+    SELECT id, 'ru' AS lang, example_ru AS example FROM grammar_examples
+    UNION ALL
+    SELECT id, 'en', example_en FROM grammar_examples
+    UNION ALL
+    SELECT id, 'de', example_de FROM grammar_examples
+) t
+WHERE coalesce(example, '') <> '';
+
+ALTER TABLE grammar_examples
+    DROP COLUMN example_ru, DROP COLUMN example_en, DROP COLUMN example_de;
+
+CREATE TABLE grammar_exception_translations (
+    exception_id INTEGER NOT NULL REFERENCES grammar_exceptions (id) ON DELETE CASCADE,
+    lang         TEXT    NOT NULL,
+    description  TEXT    NOT NULL DEFAULT '',
+    explanation  TEXT    NOT NULL DEFAULT '',
+    PRIMARY KEY (exception_id, lang)
+);
+
+INSERT INTO grammar_exception_translations (exception_id, lang, description, explanation)
+SELECT id, lang, coalesce(description, ''), coalesce(explanation, '')
+FROM (
+    SELECT id, 'ru' AS lang, description_ru AS description, explanation_ru AS explanation FROM grammar_exceptions
+    UNION ALL
+    SELECT id, 'en', description_en, explanation_en FROM grammar_exceptions
+    UNION ALL
+    SELECT id, 'de', description_de, explanation_de FROM grammar_exceptions
+) t
+WHERE coalesce(description, '') <> '' OR coalesce(explanation, '') <> '';
+
+ALTER TABLE grammar_exceptions
+    DROP COLUMN description_ru, DROP COLUMN description_en, DROP COLUMN description_de,
+    DROP COLUMN explanation_ru, DROP COLUMN explanation_en, DROP COLUMN explanation_de;
@@ -0,0 +1,17 @@
+DROP TRIGGER grammar_rule_translations_search_vector ON grammar_rule_translations;
+DROP TRIGGER grammar_translations_search_vector ON grammar_translations;
+DROP TRIGGER text_translations_search_vector ON text_translations;
+DROP TRIGGER word_translations_search_vector ON word_translations;
+
+DROP FUNCTION grammar_rule_translations_search_vector();
+DROP FUNCTION grammar_translations_search_vector();
+DROP FUNCTION text_translations_search_vector();
+DROP FUNCTION word_translations_search_vector();
+
+ALTER TABLE grammar_rule_translations DROP COLUMN search_vector;
+ALTER TABLE grammar_translations      DROP COLUMN search_vector;
+ALTER TABLE text_translations         DROP COLUMN search_vector;
+ALTER TABLE word_translations         DROP COLUMN search_vector;
+
+DROP FUNCTION lang_ts_config(TEXT);
+DROP TABLE text_search_configs;
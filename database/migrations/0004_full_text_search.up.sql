@@ -0,0 +1,81 @@
+-- Полнотекстовый поиск: tsvector в таблицах переводов с конфигурацией по языку строки.
+-- Соответствие язык → конфигурация хранится в text_search_configs; при старте
+-- сервер синхронизирует её с реестром языков.
+
+CREATE TABLE text_search_configs (
+    lang   TEXT PRIMARY KEY,
+    config REGCONFIG NOT NULL
+);
+
+INSERT INTO text_search_configs (lang, config) VALUES
+    ('ru', 'russian'),
+    ('en', 'english'),
+    ('de', 'german');
+
+CREATE FUNCTION lang_ts_config(code TEXT) RETURNS REGCONFIG
+LANGUAGE sql STABLE AS $$
+    SELECT coalesce((SELECT config FROM text_search_configs WHERE lang = code), 'simple'::regconfig)
+$$;
+
+ALTER TABLE word_translations         ADD COLUMN search_vector TSVECTOR;
+ALTER TABLE text_translations         ADD COLUMN search_vector TSVECTOR;
+ALTER TABLE grammar_translations      ADD COLUMN search_vector TSVECTOR;
+ALTER TABLE grammar_rule_translations ADD COLUMN search_vector TSVECTOR;
+
+CREATE FUNCTION word_translations_search_vector() RETURNS trigger
+LANGUAGE plpgsql AS $$
+BEGIN
+    NEW.search_vector := setweight(to_tsvector(lang_ts_config(NEW.lang), coalesce(NEW.word, '')), 'A');
+    RETURN NEW;
+END
+$$;
+
+CREATE FUNCTION text_translations_search_vector() RETURNS trigger
+LANGUAGE plpgsql AS $$
+BEGIN
+    NEW.search_vector :=
+        setweight(to_tsvector(lang_ts_config(NEW.lang), coalesce(NEW.title, '')), 'A') ||
+        setweight(to_tsvector(lang_ts_config(NEW.lang), coalesce(NEW.content, '')), 'B');
+    RETURN NEW;
+END
+$$;
+
+CREATE FUNCTION grammar_translations_search_vector() RETURNS trigger
+LANGUAGE plpgsql AS $$
+BEGIN
+    NEW.search_vector :=
+        setweight(to_tsvector(lang_ts_config(NEW.lang), coalesce(NEW.title, '')), 'A') ||
+        setweight(to_tsvector(lang_ts_config(NEW.lang), coalesce(NEW.description, '')), 'B');
+    RETURN NEW;
+END
+$$;
+
+CREATE FUNCTION grammar_rule_translations_search_vector() RETURNS trigger
+LANGUAGE plpgsql AS $$
+BEGIN
+    NEW.search_vector :=
+        setweight(to_tsvector(lang_ts_config(NEW.lang), coalesce(NEW.name, '')), 'A') ||
+        setweight(to_tsvector(lang_ts_config(NEW.lang), coalesce(NEW.description, '')), 'B');
+    RETURN NEW;
+END
+$$;
+
+CREATE TRIGGER word_translations_search_vector BEFORE INSERT OR UPDATE ON word_translations
+    FOR EACH ROW EXECUTE FUNCTION word_translations_search_vector();
+CREATE TRIGGER text_translations_search_vector BEFORE INSERT OR UPDATE ON text_translations
+    FOR EACH ROW EXECUTE FUNCTION text_translations_search_vector();
+CREATE TRIGGER grammar_translations_search_vector BEFORE INSERT OR UPDATE ON grammar_translations
+    FOR EACH ROW EXECUTE FUNCTION grammar_translations_search_vector();
+CREATE TRIGGER grammar_rule_translations_search_vector BEFORE INSERT OR UPDATE ON grammar_rule_translations
+    FOR EACH ROW EXECUTE FUNCTION grammar_rule_translations_search_vector();
+
+-- заполнение для существующих строк: UPDATE запускает триггеры
+UPDATE word_translations SET lang = lang;
+UPDATE text_translations SET lang = lang;
+UPDATE grammar_translations SET lang = lang;
+UPDATE grammar_rule_translations SET lang = lang;
+
+CREATE INDEX idx_word_translations_search ON word_translations USING gin (search_vector);
+CREATE INDEX idx_text_translations_search ON text_translations USING gin (search_vector);
+CREATE INDEX idx_grammar_translations_search ON grammar_translations USING gin (search_vector);
+CREATE INDEX idx_grammar_rule_translations_search ON grammar_rule_translations USING gin (search_vector);
@@ -0,0 +1,3 @@
+DROP INDEX idx_word_translations_word_trgm;
+DROP FUNCTION fold_text(TEXT);
+-- расширение pg_trgm не удаляем: им могут пользоваться другие объекты
@@ -0,0 +1,13 @@
+-- Нечёткий поиск слов: триграммы pg_trgm по нормализованной форме.
+-- fold_text приводит к нижнему регистру и сворачивает ё→е, умлауты и ß,
+-- чтобы "strasse" находило "Straße", а "еж" — "ёж".
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE FUNCTION fold_text(s TEXT) RETURNS TEXT
+LANGUAGE sql IMMUTABLE PARALLEL SAFE AS $$
+    SELECT replace(translate(lower(s), 'ёЁäÄöÖüÜéèêàâç', 'ееaaoouueeeaac'), 'ß', 'ss')
+$$;
+
+CREATE INDEX idx_word_translations_word_trgm
+    ON word_translations USING gin (fold_text(word) gin_trgm_ops);
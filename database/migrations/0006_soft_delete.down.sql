@@ -0,0 +1,16 @@
+-- удалённые мягко строки удаляются окончательно, иначе после отката они «оживут»
+DELETE FROM grammar_exceptions WHERE deleted_at IS NOT NULL;
+DELETE FROM grammar_examples   WHERE deleted_at IS NOT NULL;
+DELETE FROM grammar_rules      WHERE deleted_at IS NOT NULL;
+DELETE FROM grammars           WHERE deleted_at IS NOT NULL;
+DELETE FROM texts              WHERE deleted_at IS NOT NULL;
+DELETE FROM words              WHERE deleted_at IS NOT NULL;
+DELETE FROM categories         WHERE deleted_at IS NOT NULL;
+
+ALTER TABLE grammar_exceptions DROP COLUMN deleted_at;
+ALTER TABLE grammar_examples   DROP COLUMN deleted_at;
+ALTER TABLE grammar_rules      DROP COLUMN deleted_at;
+ALTER TABLE grammars           DROP COLUMN deleted_at;
+ALTER TABLE texts              DROP COLUMN deleted_at;
+ALTER TABLE words              DROP COLUMN deleted_at;
+ALTER TABLE categories         DROP COLUMN deleted_at;
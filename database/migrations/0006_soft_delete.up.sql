@@ -0,0 +1,19 @@
+-- Мягкое удаление: строка с deleted_at считается удалённой и лежит в корзине,
+-- пока её не восстановят или не вычистит фоновая задача.
+
+ALTER TABLE categories         ADD COLUMN deleted_at TIMESTAMPTZ;
+ALTER TABLE words              ADD COLUMN deleted_at TIMESTAMPTZ;
+ALTER TABLE texts              ADD COLUMN deleted_at TIMESTAMPTZ;
+ALTER TABLE grammars           ADD COLUMN deleted_at TIMESTAMPTZ;
+ALTER TABLE grammar_rules      ADD COLUMN deleted_at TIMESTAMPTZ;
+ALTER TABLE grammar_examples   ADD COLUMN deleted_at TIMESTAMPTZ;
+ALTER TABLE grammar_exceptions ADD COLUMN deleted_at TIMESTAMPTZ;
+
+-- частичные индексы: нужны только корзине и очистке
+CREATE INDEX idx_categories_deleted_at         ON categories (deleted_at)         WHERE deleted_at IS NOT NULL;
+CREATE INDEX idx_words_deleted_at              ON words (deleted_at)              WHERE deleted_at IS NOT NULL;
+CREATE INDEX idx_texts_deleted_at              ON texts (deleted_at)              WHERE deleted_at IS NOT NULL;
+CREATE INDEX idx_grammars_deleted_at           ON grammars (deleted_at)           WHERE deleted_at IS NOT NULL;
+CREATE INDEX idx_grammar_rules_deleted_at      ON grammar_rules (deleted_at)      WHERE deleted_at IS NOT NULL;
+CREATE INDEX idx_grammar_examples_deleted_at   ON grammar_examples (deleted_at)   WHERE deleted_at IS NOT NULL;
+CREATE INDEX idx_grammar_exceptions_deleted_at ON grammar_exceptions (deleted_at) WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,13 @@
+ALTER TABLE grammar_exceptions DROP CONSTRAINT grammar_exceptions_rule_id_fkey;
+ALTER TABLE grammar_examples   DROP CONSTRAINT grammar_examples_rule_id_fkey;
+ALTER TABLE grammar_rules      DROP CONSTRAINT grammar_rules_grammar_id_fkey;
+ALTER TABLE texts              DROP CONSTRAINT texts_category_id_fkey;
+ALTER TABLE words              DROP CONSTRAINT words_category_id_fkey;
+
+DROP INDEX idx_texts_category_id;
+DROP INDEX idx_words_category_id;
+
+UPDATE texts SET category_id = 0 WHERE category_id IS NULL;
+UPDATE words SET category_id = 0 WHERE category_id IS NULL;
+ALTER TABLE texts ALTER COLUMN category_id SET DEFAULT 0, ALTER COLUMN category_id SET NOT NULL;
+ALTER TABLE words ALTER COLUMN category_id SET DEFAULT 0, ALTER COLUMN category_id SET NOT NULL;
@@ -0,0 +1,26 @@
+-- Внешние ключи между сущностями. «Без категории» теперь NULL, а не 0.
+-- Ключи создаются NOT VALID: новые и изменённые строки проверяются сразу,
+-- а старые висячие ссылки видны в GET /api/admin/orphans. Когда их не останется,
+-- ограничения можно проверить: ALTER TABLE ... VALIDATE CONSTRAINT ...
+
+ALTER TABLE words ALTER COLUMN category_id DROP NOT NULL, ALTER COLUMN category_id DROP DEFAULT;
+ALTER TABLE texts ALTER COLUMN category_id DROP NOT NULL, ALTER COLUMN category_id DROP DEFAULT;
+UPDATE words SET category_id = NULL WHERE category_id = 0;
+UPDATE texts SET category_id = NULL WHERE category_id = 0;
+
+CREATE INDEX IF NOT EXISTS idx_words_category_id ON words (category_id);
+CREATE INDEX IF NOT EXISTS idx_texts_category_id ON texts (category_id);
+
+-- категорию с записями удалить нельзя: их нужно перенести (?reassign_to=)
+ALTER TABLE words ADD CONSTRAINT words_category_id_fkey
+    FOREIGN KEY (category_id) REFERENCES categories (id) ON DELETE RESTRICT NOT VALID;
+ALTER TABLE texts ADD CONSTRAINT texts_category_id_fkey
+    FOREIGN KEY (category_id) REFERENCES categories (id) ON DELETE RESTRICT NOT VALID;
+
+-- дочерние записи грамматики удаляются вместе с родителем
+ALTER TABLE grammar_rules ADD CONSTRAINT grammar_rules_grammar_id_fkey
+    FOREIGN KEY (grammar_id) REFERENCES grammars (id) ON DELETE CASCADE NOT VALID;
+ALTER TABLE grammar_examples ADD CONSTRAINT grammar_examples_rule_id_fkey
+    FOREIGN KEY (rule_id) REFERENCES grammar_rules (id) ON DELETE CASCADE NOT VALID;
+ALTER TABLE grammar_exceptions ADD CONSTRAINT grammar_exceptions_rule_id_fkey
+    FOREIGN KEY (rule_id) REFERENCES grammar_rules (id) ON DELETE CASCADE NOT VALID;
@@ -0,0 +1,7 @@
+ALTER TABLE grammar_exceptions DROP COLUMN version;
+ALTER TABLE grammar_examples   DROP COLUMN version;
+ALTER TABLE grammar_rules      DROP COLUMN version;
+ALTER TABLE grammars           DROP COLUMN version;
+ALTER TABLE texts              DROP COLUMN version;
+ALTER TABLE words              DROP COLUMN version;
+ALTER TABLE categories         DROP COLUMN version;
@@ -0,0 +1,10 @@
+-- Версия записи для оптимистичной блокировки: растёт при каждом изменении,
+-- отдаётся клиенту в ETag и сверяется с If-Match.
+
+ALTER TABLE categories         ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE words              ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE texts              ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE grammars           ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE grammar_rules      ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE grammar_examples   ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE grammar_exceptions ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,12 @@
+-- История правок: снимки записи до и после изменения (JSON как в API, без клипов)
+CREATE TABLE revisions (
+    id          BIGSERIAL PRIMARY KEY,
+    entity_type TEXT NOT NULL,
+    entity_id   INTEGER NOT NULL,
+    action      TEXT NOT NULL,
+    before      JSONB,
+    after       JSONB,
+    actor       TEXT NOT NULL DEFAULT '',
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX idx_revisions_entity ON revisions (entity_type, entity_id, id);
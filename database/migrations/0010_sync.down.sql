@@ -0,0 +1,34 @@
+DROP TRIGGER grammar_exception_translations_touch_owner ON grammar_exception_translations;
+DROP TRIGGER grammar_example_translations_touch_owner ON grammar_example_translations;
+DROP TRIGGER grammar_rule_translations_touch_owner ON grammar_rule_translations;
+DROP TRIGGER grammar_translations_touch_owner ON grammar_translations;
+DROP TRIGGER text_translations_touch_owner ON text_translations;
+DROP TRIGGER word_translations_touch_owner ON word_translations;
+DROP TRIGGER category_translations_touch_owner ON category_translations;
+DROP TRIGGER grammar_exceptions_log_change ON grammar_exceptions;
+DROP TRIGGER grammar_exceptions_touch ON grammar_exceptions;
+DROP TRIGGER grammar_examples_log_change ON grammar_examples;
+DROP TRIGGER grammar_examples_touch ON grammar_examples;
+DROP TRIGGER grammar_rules_log_change ON grammar_rules;
+DROP TRIGGER grammar_rules_touch ON grammar_rules;
+DROP TRIGGER grammars_log_change ON grammars;
+DROP TRIGGER grammars_touch ON grammars;
+DROP TRIGGER texts_log_change ON texts;
+DROP TRIGGER texts_touch ON texts;
+DROP TRIGGER words_log_change ON words;
+DROP TRIGGER words_touch ON words;
+DROP TRIGGER categories_log_change ON categories;
+DROP TRIGGER categories_touch ON categories;
+
+DROP FUNCTION touch_owner();
+DROP FUNCTION log_change();
+DROP FUNCTION touch_updated_at();
+DROP TABLE changes;
+
+ALTER TABLE grammar_exceptions DROP COLUMN updated_at;
+ALTER TABLE grammar_examples   DROP COLUMN updated_at;
+ALTER TABLE grammar_rules      DROP COLUMN updated_at;
+ALTER TABLE grammars           DROP COLUMN updated_at;
+ALTER TABLE texts              DROP COLUMN updated_at;
+ALTER TABLE words              DROP COLUMN updated_at;
+ALTER TABLE categories         DROP COLUMN updated_at;
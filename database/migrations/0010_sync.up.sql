@@ -0,0 +1,111 @@
+-- Синхронизация: updated_at у всех записей и журнал изменённых записей для GET /api/sync.
+-- Журнал ведут триггеры, поэтому в него попадает любая запись в БД, включая фоновые задачи.
+-- txid — транзакция изменения: токен клиента — xmin снимка, в котором он читал журнал
+
+ALTER TABLE categories         ADD COLUMN updated_at TIMESTAMPTZ NOT NULL DEFAULT now();
+ALTER TABLE words              ADD COLUMN updated_at TIMESTAMPTZ NOT NULL DEFAULT now();
+ALTER TABLE texts              ADD COLUMN updated_at TIMESTAMPTZ NOT NULL DEFAULT now();
+ALTER TABLE grammars           ADD COLUMN updated_at TIMESTAMPTZ NOT NULL DEFAULT now();
+ALTER TABLE grammar_rules      ADD COLUMN updated_at TIMESTAMPTZ NOT NULL DEFAULT now();
+ALTER TABLE grammar_examples   ADD COLUMN updated_at TIMESTAMPTZ NOT NULL DEFAULT now();
+ALTER TABLE grammar_exceptions ADD COLUMN updated_at TIMESTAMPTZ NOT NULL DEFAULT now();
+
+CREATE TABLE changes (
+    id          BIGSERIAL PRIMARY KEY,
+    entity_type TEXT NOT NULL,
+    entity_id   INTEGER NOT NULL,
+    txid        XID8 NOT NULL DEFAULT pg_current_xact_id(),
+    changed_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX idx_changes_txid ON changes (txid);
+
+CREATE FUNCTION touch_updated_at() RETURNS trigger
+LANGUAGE plpgsql AS $$
+BEGIN
+    NEW.updated_at := now();
+    RETURN NEW;
+END
+$$;
+
+-- одна строка журнала на запись и транзакцию
+CREATE FUNCTION log_change() RETURNS trigger
+LANGUAGE plpgsql AS $$
+DECLARE
+    rec_id INTEGER;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        rec_id := OLD.id;
+    ELSE
+        rec_id := NEW.id;
+    END IF;
+    INSERT INTO changes (entity_type, entity_id)
+    SELECT TG_TABLE_NAME, rec_id
+    WHERE NOT EXISTS (
+        SELECT 1 FROM changes
+        WHERE txid = pg_current_xact_id() AND entity_type = TG_TABLE_NAME AND entity_id = rec_id
+    );
+    RETURN NULL;
+END
+$$;
+
+-- изменение перевода обновляет updated_at владельца (TG_ARGV: таблица владельца, колонка ссылки),
+-- а тот уже попадает в журнал своим триггером
+CREATE FUNCTION touch_owner() RETURNS trigger
+LANGUAGE plpgsql AS $$
+DECLARE
+    owner_id INTEGER;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        owner_id := (to_jsonb(OLD) ->> TG_ARGV[1])::INTEGER;
+    ELSE
+        owner_id := (to_jsonb(NEW) ->> TG_ARGV[1])::INTEGER;
+    END IF;
+    EXECUTE format('UPDATE %I SET updated_at = now() WHERE id = $1 AND updated_at <> now()', TG_ARGV[0])
+        USING owner_id;
+    RETURN NULL;
+END
+$$;
+
+CREATE TRIGGER categories_touch BEFORE INSERT OR UPDATE ON categories
+    FOR EACH ROW EXECUTE FUNCTION touch_updated_at();
+CREATE TRIGGER categories_log_change AFTER INSERT OR UPDATE OR DELETE ON categories
+    FOR EACH ROW EXECUTE FUNCTION log_change();
+CREATE TRIGGER words_touch BEFORE INSERT OR UPDATE ON words
+    FOR EACH ROW EXECUTE FUNCTION touch_updated_at();
+CREATE TRIGGER words_log_change AFTER INSERT OR UPDATE OR DELETE ON words
+    FOR EACH ROW EXECUTE FUNCTION log_change();
+CREATE TRIGGER texts_touch BEFORE INSERT OR UPDATE ON texts
+    FOR EACH ROW EXECUTE FUNCTION touch_updated_at();
+CREATE TRIGGER texts_log_change AFTER INSERT OR UPDATE OR DELETE ON texts
+    FOR EACH ROW EXECUTE FUNCTION log_change();
+CREATE TRIGGER grammars_touch BEFORE INSERT OR UPDATE ON grammars
+    FOR EACH ROW EXECUTE FUNCTION touch_updated_at();
+CREATE TRIGGER grammars_log_change AFTER INSERT OR UPDATE OR DELETE ON grammars
+    FOR EACH ROW EXECUTE FUNCTION log_change();
+CREATE TRIGGER grammar_rules_touch BEFORE INSERT OR UPDATE ON grammar_rules
+    FOR EACH ROW EXECUTE FUNCTION touch_updated_at();
+CREATE TRIGGER grammar_rules_log_change AFTER INSERT OR UPDATE OR DELETE ON grammar_rules
+    FOR EACH ROW EXECUTE FUNCTION log_change();
+CREATE TRIGGER grammar_examples_touch BEFORE INSERT OR UPDATE ON grammar_examples
+    FOR EACH ROW EXECUTE FUNCTION touch_updated_at();
+CREATE TRIGGER grammar_examples_log_change AFTER INSERT OR UPDATE OR DELETE ON grammar_examples
+    FOR EACH ROW EXECUTE FUNCTION log_change();
+CREATE TRIGGER grammar_exceptions_touch BEFORE INSERT OR UPDATE ON grammar_exceptions
+    FOR EACH ROW EXECUTE FUNCTION touch_updated_at();
+CREATE TRIGGER grammar_exceptions_log_change AFTER INSERT OR UPDATE OR DELETE ON grammar_exceptions
+    FOR EACH ROW EXECUTE FUNCTION log_change();
+
+CREATE TRIGGER category_translations_touch_owner AFTER INSERT OR UPDATE OR DELETE ON category_translations
+    FOR EACH ROW EXECUTE FUNCTION touch_owner('categories', 'category_id');
+CREATE TRIGGER word_translations_touch_owner AFTER INSERT OR UPDATE OR DELETE ON word_translations
+    FOR EACH ROW EXECUTE FUNCTION touch_owner('words', 'word_id');
+CREATE TRIGGER text_translations_touch_owner AFTER INSERT OR UPDATE OR DELETE ON text_translations
+    FOR EACH ROW EXECUTE FUNCTION touch_owner('texts', 'text_id');
+CREATE TRIGGER grammar_translations_touch_owner AFTER INSERT OR UPDATE OR DELETE ON grammar_translations
+    FOR EACH ROW EXECUTE FUNCTION touch_owner('grammars', 'grammar_id');
+CREATE TRIGGER grammar_rule_translations_touch_owner AFTER INSERT OR UPDATE OR DELETE ON grammar_rule_translations
+    FOR EACH ROW EXECUTE FUNCTION touch_owner('grammar_rules', 'rule_id');
+CREATE TRIGGER grammar_example_translations_touch_owner AFTER INSERT OR UPDATE OR DELETE ON grammar_example_translations
+    FOR EACH ROW EXECUTE FUNCTION touch_owner('grammar_examples', 'example_id');
+CREATE TRIGGER grammar_exception_translations_touch_owner AFTER INSERT OR UPDATE OR DELETE ON grammar_exception_translations
+    FOR EACH ROW EXECUTE FUNCTION touch_owner('grammar_exceptions', 'exception_id');
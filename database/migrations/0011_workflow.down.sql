@@ -0,0 +1,12 @@
+DROP INDEX idx_grammars_status;
+DROP INDEX idx_texts_status;
+DROP INDEX idx_words_status;
+
+ALTER TABLE grammars DROP COLUMN publish_at;
+ALTER TABLE texts    DROP COLUMN publish_at;
+ALTER TABLE words    DROP COLUMN publish_at;
+
+ALTER TABLE grammars DROP COLUMN status;
+ALTER TABLE texts    DROP COLUMN status;
+ALTER TABLE words    DROP CONSTRAINT words_status_check;
+ALTER TABLE words    ALTER COLUMN status SET DEFAULT '';
@@ -0,0 +1,24 @@
+-- Редакционный процесс: статус draft → in_review → published → archived у слов, текстов и грамматик
+-- и publish_at для отложенной публикации. Всё, что уже есть в базе, считается опубликованным,
+-- кроме слов, у которых статус уже был выставлен одним из допустимых значений
+
+UPDATE words SET status = 'published'
+WHERE status NOT IN ('draft', 'in_review', 'published', 'archived');
+ALTER TABLE words ALTER COLUMN status SET DEFAULT 'draft';
+
+ALTER TABLE texts    ADD COLUMN status TEXT NOT NULL DEFAULT 'published';
+ALTER TABLE grammars ADD COLUMN status TEXT NOT NULL DEFAULT 'published';
+ALTER TABLE texts    ALTER COLUMN status SET DEFAULT 'draft';
+ALTER TABLE grammars ALTER COLUMN status SET DEFAULT 'draft';
+
+ALTER TABLE words    ADD CONSTRAINT words_status_check    CHECK (status IN ('draft', 'in_review', 'published', 'archived'));
+ALTER TABLE texts    ADD CONSTRAINT texts_status_check    CHECK (status IN ('draft', 'in_review', 'published', 'archived'));
+ALTER TABLE grammars ADD CONSTRAINT grammars_status_check CHECK (status IN ('draft', 'in_review', 'published', 'archived'));
+
+ALTER TABLE words    ADD COLUMN publish_at TIMESTAMPTZ;
+ALTER TABLE texts    ADD COLUMN publish_at TIMESTAMPTZ;
+ALTER TABLE grammars ADD COLUMN publish_at TIMESTAMPTZ;
+
+CREATE INDEX idx_words_status    ON words (status);
+CREATE INDEX idx_texts_status    ON texts (status);
+CREATE INDEX idx_grammars_status ON grammars (status);
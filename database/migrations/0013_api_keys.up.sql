@@ -0,0 +1,14 @@
+-- API-ключи для скриптов и CI. Хранится только SHA-256 ключа: сам ключ показывается один раз при выпуске.
+-- scopes — права через пробел
+CREATE TABLE api_keys (
+    id           SERIAL PRIMARY KEY,
+    name         TEXT NOT NULL,
+    prefix       TEXT NOT NULL,
+    key_hash     TEXT NOT NULL UNIQUE,
+    scopes       TEXT NOT NULL,
+    created_by   INTEGER REFERENCES users (id) ON DELETE SET NULL,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at   TIMESTAMPTZ,
+    last_used_at TIMESTAMPTZ,
+    revoked_at   TIMESTAMPTZ
+);
@@ -0,0 +1,31 @@
+-- Интервальное повторение слов: состояние FSRS для каждой пары пользователь–слово
+-- и журнал оценок. id в журнале задаёт клиент, поэтому повторная отправка той же
+-- оценки (например, после офлайна) не применяется дважды
+CREATE TABLE review_states (
+    user_id        INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    word_id        INTEGER NOT NULL REFERENCES words (id) ON DELETE CASCADE,
+    state          TEXT NOT NULL,
+    stability      DOUBLE PRECISION NOT NULL,
+    difficulty     DOUBLE PRECISION NOT NULL,
+    reps           INTEGER NOT NULL DEFAULT 0,
+    lapses         INTEGER NOT NULL DEFAULT 0,
+    due_at         TIMESTAMPTZ NOT NULL,
+    last_review_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (user_id, word_id)
+);
+CREATE INDEX idx_review_states_due ON review_states (user_id, due_at);
+
+CREATE TABLE review_log (
+    id           TEXT NOT NULL,
+    user_id      INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    word_id      INTEGER NOT NULL REFERENCES words (id) ON DELETE CASCADE,
+    rating       SMALLINT NOT NULL CHECK (rating BETWEEN 1 AND 4),
+    state        TEXT NOT NULL,
+    stability    DOUBLE PRECISION NOT NULL,
+    difficulty   DOUBLE PRECISION NOT NULL,
+    due_at       TIMESTAMPTZ NOT NULL,
+    reviewed_at  TIMESTAMPTZ NOT NULL,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (user_id, id)
+);
+CREATE INDEX idx_review_log_user ON review_log (user_id, reviewed_at);
@@ -0,0 +1,24 @@
+-- Прогресс ученика по словам, текстам и правилам грамматики: статус
+-- (seen → learning → mastered) и время, проведённое с материалом.
+-- Статус слов выводится из повторений, у текстов и правил его отмечает клиент
+CREATE TABLE progress (
+    user_id       INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    entity        TEXT    NOT NULL CHECK (entity IN ('words', 'texts', 'grammar_rules')),
+    item_id       INTEGER NOT NULL,
+    status        TEXT    NOT NULL DEFAULT 'seen' CHECK (status IN ('seen', 'learning', 'mastered')),
+    time_spent    INTEGER NOT NULL DEFAULT 0,
+    first_seen_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+    mastered_at   TIMESTAMPTZ,
+    PRIMARY KEY (user_id, entity, item_id)
+);
+CREATE INDEX idx_progress_mastered ON progress (user_id, mastered_at) WHERE mastered_at IS NOT NULL;
+
+-- слова, которые уже повторялись
+INSERT INTO progress (user_id, entity, item_id, status, first_seen_at, updated_at, mastered_at)
+SELECT rs.user_id, 'words', rs.word_id,
+       CASE WHEN rs.state = 'review' AND rs.stability >= 21 THEN 'mastered' ELSE 'learning' END,
+       coalesce((SELECT min(l.reviewed_at) FROM review_log l WHERE l.user_id = rs.user_id AND l.word_id = rs.word_id), rs.last_review_at),
+       rs.last_review_at,
+       CASE WHEN rs.state = 'review' AND rs.stability >= 21 THEN rs.last_review_at END
+FROM review_states rs;
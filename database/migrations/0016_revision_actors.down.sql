@@ -0,0 +1,4 @@
+UPDATE revisions SET actor = '' WHERE actor = 'anonymous';
+
+UPDATE revisions SET actor = substr(actor, length('unverified:') + 1)
+WHERE actor LIKE 'unverified:%';
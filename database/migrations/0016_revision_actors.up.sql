@@ -0,0 +1,7 @@
+-- До появления пользователей автор ревизии брался из заголовка X-Actor, который мог
+-- прислать кто угодно. Такие авторы помечаются как непроверенные, пустые — как anonymous
+UPDATE revisions SET actor = 'unverified:' || actor
+WHERE actor <> ''
+  AND created_at < COALESCE((SELECT applied_at FROM schema_migrations WHERE version = 12), now());
+
+UPDATE revisions SET actor = 'anonymous' WHERE actor = '';
@@ -0,0 +1,2 @@
+DROP INDEX idx_changes_changed_at;
+DROP TABLE changes_horizon;
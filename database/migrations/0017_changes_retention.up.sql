@@ -0,0 +1,9 @@
+-- Горизонт журнала changes: самая поздняя транзакция, изменения которой уже вычищены.
+-- Токен синхронизации не новее горизонта значит, что клиент мог пропустить изменения
+CREATE TABLE changes_horizon (
+    id   BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+    txid XID8 NOT NULL DEFAULT '0'
+);
+INSERT INTO changes_horizon DEFAULT VALUES;
+
+CREATE INDEX idx_changes_changed_at ON changes (changed_at);
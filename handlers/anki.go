@@ -0,0 +1,326 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"database/sql"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"html"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"bd_back_for_translate_app/audio"
+	"bd_back_for_translate_app/languages"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	_ "modernc.org/sqlite"
+)
+
+// Пакет Anki (.apkg) — zip с коллекцией collection.anki2 (SQLite, схема 11, её читают
+// все версии Anki), файлом media (JSON «номер → имя файла») и самими медиафайлами под номерами
+
+const ankiSchema = `
+CREATE TABLE col (id integer primary key, crt integer not null, mod integer not null, scm integer not null,
+    ver integer not null, dty integer not null, usn integer not null, ls integer not null, conf text not null,
+    models text not null, decks text not null, dconf text not null, tags text not null);
+CREATE TABLE notes (id integer primary key, guid text not null, mid integer not null, mod integer not null,
+    usn integer not null, tags text not null, flds text not null, sfld integer not null, csum integer not null,
+    flags integer not null, data text not null);
+CREATE TABLE cards (id integer primary key, nid integer not null, did integer not null, ord integer not null,
+    mod integer not null, usn integer not null, type integer not null, queue integer not null, due integer not null,
+    ivl integer not null, factor integer not null, reps integer not null, lapses integer not null, left integer not null,
+    odue integer not null, odid integer not null, flags integer not null, data text not null);
+CREATE TABLE revlog (id integer primary key, cid integer not null, usn integer not null, ease integer not null,
+    ivl integer not null, lastIvl integer not null, factor integer not null, time integer not null, type integer not null);
+CREATE TABLE graves (usn integer not null, oid integer not null, type integer not null);
+CREATE INDEX ix_notes_usn ON notes (usn);
+CREATE INDEX ix_cards_usn ON cards (usn);
+CREATE INDEX ix_revlog_usn ON revlog (usn);
+CREATE INDEX ix_cards_nid ON cards (nid);
+CREATE INDEX ix_cards_sched ON cards (did, queue, due);
+CREATE INDEX ix_revlog_cid ON revlog (cid);
+CREATE INDEX ix_notes_csum ON notes (csum);
+`
+
+// ankiCard — одна заметка: слово на языке from, перевод на to, транскрипция и клип перевода
+type ankiCard struct {
+	wordID     int
+	front      string
+	back       string
+	ipa        string
+	audioID    *string
+	audioMedia string // имя медиафайла; "" — клипа нет
+}
+
+// ExportCategoryAnki — GET /api/categories/:id/export.apkg?from=ru&to=de.
+// Одна заметка на слово категории: лицевая сторона — слово на from, оборот — на to
+// с транскрипцией и озвучкой. guid заметок постоянный, поэтому повторный импорт
+// обновляет карточки, а не дублирует их. Слова без перевода на один из языков пропускаются
+func ExportCategoryAnki(c *gin.Context) {
+	id, ok := getID(c)
+	if !ok {
+		return
+	}
+	from, to := c.Query("from"), c.Query("to")
+	if !languages.Supported(from) || !languages.Supported(to) || from == to {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from and to must be two different supported languages"})
+		return
+	}
+
+	var cat Category
+	if err := DB.Preload("Translations").First(&cat, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "category not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	var words []Word
+	if err := visibleTo(c, "words", DB.Preload("Translations")).Where("category_id = ?", id).Order("id").Find(&words).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	cards := make([]ankiCard, 0, len(words))
+	for _, w := range words {
+		var src, dst *WordTranslation
+		for i := range w.Translations {
+			switch w.Translations[i].Lang {
+			case from:
+				src = &w.Translations[i]
+			case to:
+				dst = &w.Translations[i]
+			}
+		}
+		if src == nil || dst == nil || src.Word == "" || dst.Word == "" {
+			continue
+		}
+		cards = append(cards, ankiCard{wordID: w.ID, front: src.Word, back: dst.Word, ipa: dst.Transcription, audioID: dst.AudioID})
+	}
+
+	deckName := fmt.Sprintf("Category %d", id)
+	for _, t := range cat.Translations {
+		if t.Lang == from && t.Name != "" {
+			deckName = t.Name
+		}
+	}
+	data, err := buildAnkiPackage(c.Request.Context(), id, deckName, from, to, cards)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="category-%d-%s-%s.apkg"`, id, from, to))
+	c.Header("X-Anki-Notes", strconv.Itoa(len(cards)))
+	c.Header("X-Anki-Skipped", strconv.Itoa(len(words)-len(cards)))
+	c.Data(http.StatusOK, "application/apkg", data)
+}
+
+// buildAnkiPackage собирает .apkg в памяти: коллекция создаётся во временном файле
+func buildAnkiPackage(ctx context.Context, categoryID int, deckName, from, to string, cards []ankiCard) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	zw := zip.NewWriter(buf)
+
+	// медиа: клипы кладутся один раз, в заметке на них ссылается [sound:имя]
+	media := map[string]string{}
+	names := map[string]string{}
+	for i := range cards {
+		cd := &cards[i]
+		if cd.audioID == nil || AudioStore == nil {
+			continue
+		}
+		if name, ok := names[*cd.audioID]; ok {
+			cd.audioMedia = name
+			continue
+		}
+		wav, err := audio.ReadAll(ctx, AudioStore, *cd.audioID)
+		if errors.Is(err, audio.ErrNotFound) {
+			log.Printf("[anki] word id=%d: clip %s is missing", cd.wordID, *cd.audioID)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		name := *cd.audioID + audioExt(wav)
+		key := strconv.Itoa(len(media))
+		f, err := zw.CreateHeader(&zip.FileHeader{Name: key, Method: zip.Store})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := f.Write(wav); err != nil {
+			return nil, err
+		}
+		media[key] = name
+		names[*cd.audioID] = name
+		cd.audioMedia = name
+	}
+
+	collection, err := buildAnkiCollection(categoryID, deckName, from, to, cards)
+	if err != nil {
+		return nil, err
+	}
+	f, err := zw.Create("collection.anki2")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := f.Write(collection); err != nil {
+		return nil, err
+	}
+	mediaJSON, err := json.Marshal(media)
+	if err != nil {
+		return nil, err
+	}
+	if f, err = zw.Create("media"); err != nil {
+		return nil, err
+	}
+	if _, err := f.Write(mediaJSON); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func buildAnkiCollection(categoryID int, deckName, from, to string, cards []ankiCard) ([]byte, error) {
+	dir, err := os.MkdirTemp("", "anki-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "collection.anki2")
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	if _, err := db.Exec(ankiSchema); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	// id типа заметки и колоды постоянные: при повторном импорте Anki узнаёт их
+	modelID := ankiID("model:" + from + ":" + to)
+	deckID := ankiID(fmt.Sprintf("deck:%d:%s:%s", categoryID, from, to))
+	models, decks, dconf, conf := ankiCollectionJSON(now, modelID, deckID, deckName, from, to)
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(`INSERT INTO col VALUES (1, ?, ?, ?, 11, 0, 0, 0, ?, ?, ?, ?, '{}')`,
+		now.Unix(), now.UnixMilli(), now.UnixMilli(), conf, models, decks, dconf); err != nil {
+		return nil, err
+	}
+	base := now.UnixMilli()
+	for i, cd := range cards {
+		sound := ""
+		if cd.audioMedia != "" {
+			sound = "[sound:" + cd.audioMedia + "]"
+		}
+		front := html.EscapeString(cd.front)
+		fields := strings.Join([]string{front, html.EscapeString(cd.back), html.EscapeString(cd.ipa), sound}, "\x1f")
+		noteID, cardID := base+int64(i), base+int64(i)
+		if _, err := tx.Exec(`INSERT INTO notes VALUES (?, ?, ?, ?, -1, '', ?, ?, ?, 0, '')`,
+			noteID, fmt.Sprintf("bd-%d-%s-%s", cd.wordID, from, to), modelID, now.Unix(), fields, front, ankiChecksum(front)); err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec(`INSERT INTO cards VALUES (?, ?, ?, 0, ?, -1, 0, 0, ?, 0, 0, 0, 0, 0, 0, 0, 0, '')`,
+			cardID, noteID, deckID, now.Unix(), i+1); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	if err := db.Close(); err != nil {
+		return nil, err
+	}
+	return os.ReadFile(path)
+}
+
+// ankiID — постоянный id в диапазоне миллисекундных меток, как у id, которые создаёт сама Anki
+func ankiID(key string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	return 1_500_000_000_000 + int64(h.Sum64()%100_000_000_000)
+}
+
+// ankiChecksum — csum заметки: первые 32 бита SHA-1 поля сортировки
+func ankiChecksum(field string) int64 {
+	sum := sha1.Sum([]byte(field))
+	return int64(binary.BigEndian.Uint32(sum[:4]))
+}
+
+// ankiCollectionJSON — JSON-колонки таблицы col: тип заметки, колода, настройки колоды и коллекции
+func ankiCollectionJSON(now time.Time, modelID, deckID int64, deckName, from, to string) (models, decks, dconf, conf string) {
+	field := func(name string, ord int) map[string]any {
+		return map[string]any{"name": name, "ord": ord, "sticky": false, "rtl": false, "font": "Arial", "size": 20, "media": []any{}}
+	}
+	model := map[string]any{
+		"id": modelID, "name": fmt.Sprintf("Translate app %s→%s", from, to), "type": 0,
+		"mod": now.Unix(), "usn": -1, "sortf": 0, "did": deckID, "tags": []any{}, "vers": []any{},
+		"flds": []any{field("Front", 0), field("Back", 1), field("IPA", 2), field("Audio", 3)},
+		"tmpls": []any{map[string]any{
+			"name": "Card 1", "ord": 0, "did": nil, "bqfmt": "", "bafmt": "",
+			"qfmt": "{{Front}}",
+			"afmt": "{{FrontSide}}<hr id=answer>{{Back}}<div class=ipa>{{IPA}}</div>{{Audio}}",
+		}},
+		"css":       ".card { font-family: arial; font-size: 24px; text-align: center; }\n.ipa { color: #888; font-size: 18px; }",
+		"latexPre":  "\\documentclass[12pt]{article}\n\\special{papersize=3in,5in}\n\\usepackage[utf8]{inputenc}\n\\usepackage{amssymb,amsmath}\n\\pagestyle{empty}\n\\setlength{\\parindent}{0in}\n\\begin{document}\n",
+		"latexPost": "\\end{document}",
+		"req":       []any{[]any{0, "any", []any{0}}},
+	}
+	deck := func(id int64, name string) map[string]any {
+		return map[string]any{
+			"id": id, "name": name, "mod": now.Unix(), "usn": -1, "desc": "", "dyn": 0, "conf": 1,
+			"collapsed": false, "browserCollapsed": false, "extendNew": 0, "extendRev": 0,
+			"newToday": []int{0, 0}, "revToday": []int{0, 0}, "lrnToday": []int{0, 0}, "timeToday": []int{0, 0},
+		}
+	}
+	options := map[string]any{
+		"id": 1, "name": "Default", "mod": 0, "usn": 0, "maxTaken": 60, "autoplay": true, "timer": 0,
+		"replayq": true, "dyn": false,
+		"new": map[string]any{
+			"delays": []int{1, 10}, "ints": []int{1, 4, 7}, "initialFactor": 2500, "order": 1,
+			"perDay": 20, "bury": true, "separate": true,
+		},
+		"rev": map[string]any{
+			"perDay": 200, "ease4": 1.3, "fuzz": 0.05, "minSpace": 1, "ivlFct": 1,
+			"maxIvl": 36500, "bury": true, "hardFactor": 1.2,
+		},
+		"lapse": map[string]any{
+			"delays": []int{10}, "mult": 0, "minInt": 1, "leechFails": 8, "leechAction": 0,
+		},
+	}
+	settings := map[string]any{
+		"nextPos": 1, "estTimes": true, "activeDecks": []int{1}, "sortType": "noteFld", "timeLim": 0,
+		"sortBackwards": false, "addToCur": true, "curDeck": 1, "newBury": true, "newSpread": 0,
+		"dueCounts": true, "curModel": strconv.FormatInt(modelID, 10), "collapseTime": 1200,
+	}
+
+	mustJSON := func(v any) string {
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+	models = mustJSON(map[string]any{strconv.FormatInt(modelID, 10): model})
+	decks = mustJSON(map[string]any{"1": deck(1, "Default"), strconv.FormatInt(deckID, 10): deck(deckID, deckName)})
+	dconf = mustJSON(map[string]any{"1": options})
+	conf = mustJSON(settings)
+	return
+}
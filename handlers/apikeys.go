@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql/driver"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"bd_back_for_translate_app/auth"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	apiKeyPrefix    = "bdk_"
+	apiKeyPrefixLen = len(apiKeyPrefix) + 8 // сколько первых символов ключа показывается в списке
+	// lastUsedInterval — не чаще этого last_used_at обновляется, чтобы не писать в БД на каждый запрос
+	lastUsedInterval = time.Minute
+)
+
+var errInvalidAPIKey = errors.New("invalid api key")
+
+// scopeList — права ключа; в БД хранятся строкой через пробел
+type scopeList []string
+
+func (s scopeList) Value() (driver.Value, error) {
+	return strings.Join(s, " "), nil
+}
+
+func (s *scopeList) Scan(src any) error {
+	switch v := src.(type) {
+	case string:
+		*s = strings.Fields(v)
+	case []byte:
+		*s = strings.Fields(string(v))
+	case nil:
+		*s = nil
+	default:
+		return fmt.Errorf("scopeList: unsupported type %T", src)
+	}
+	return nil
+}
+
+// APIKey — ключ доступа для скриптов; Key заполняется только в ответе на выпуск
+type APIKey struct {
+	ID         int        `gorm:"primaryKey;column:id" json:"id"`
+	Name       string     `gorm:"column:name"          json:"name"`
+	Prefix     string     `gorm:"column:prefix"        json:"prefix"`
+	KeyHash    string     `gorm:"column:key_hash"      json:"-"`
+	Scopes     scopeList  `gorm:"column:scopes"        json:"scopes"`
+	CreatedBy  *int       `gorm:"column:created_by"    json:"created_by"`
+	CreatedAt  time.Time  `gorm:"column:created_at"    json:"created_at"`
+	ExpiresAt  *time.Time `gorm:"column:expires_at"    json:"expires_at"`
+	LastUsedAt *time.Time `gorm:"column:last_used_at"  json:"last_used_at"`
+	RevokedAt  *time.Time `gorm:"column:revoked_at"    json:"revoked_at"`
+	Key        string     `gorm:"-"                    json:"key,omitempty"`
+}
+
+func (APIKey) TableName() string { return "api_keys" }
+
+// allows — есть ли у ключа право scope
+func (k *APIKey) allows(scope string) bool {
+	return slices.Contains(k.Scopes, scope)
+}
+
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// APIKeyInput — тело POST /api/api-keys
+type APIKeyInput struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+func (in APIKeyInput) validate(v *validator) {
+	if in.Name == "" {
+		v.add("name", codeRequired, "is required")
+	}
+	v.maxLen("name", in.Name, maxShortText)
+	if len(in.Scopes) == 0 {
+		v.add("scopes", codeRequired, "is required")
+	}
+	for i, s := range in.Scopes {
+		v.oneOf(fmt.Sprintf("scopes[%d]", i), s, auth.Scopes, false)
+	}
+	if in.ExpiresAt != nil && !in.ExpiresAt.After(time.Now()) {
+		v.add("expires_at", codeInvalid, "must be in the future")
+	}
+}
+
+// CreateAPIKey — POST /api/api-keys: выпуск ключа. Сам ключ есть только в этом ответе
+func CreateAPIKey(c *gin.Context) {
+	var in APIKeyInput
+	if !bindJSON(c, &in) {
+		return
+	}
+	if !validate(c, in) {
+		return
+	}
+
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	key := apiKeyPrefix + hex.EncodeToString(secret)
+	userID := currentUser(c).UserID()
+	obj := APIKey{
+		Name:      in.Name,
+		Prefix:    key[:apiKeyPrefixLen],
+		KeyHash:   hashAPIKey(key),
+		Scopes:    in.Scopes,
+		CreatedBy: &userID,
+		ExpiresAt: in.ExpiresAt,
+	}
+	if err := DB.Create(&obj).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	obj.Key = key
+	c.JSON(http.StatusCreated, obj)
+}
+
+// GetAPIKeys — GET /api/api-keys, включая отозванные
+func GetAPIKeys(c *gin.Context) {
+	keys := []APIKey{}
+	if err := DB.Order("id").Find(&keys).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, keys)
+}
+
+// RevokeAPIKey — DELETE /api/api-keys/:id. Ключ остаётся в списке с revoked_at
+func RevokeAPIKey(c *gin.Context) {
+	id, ok := getID(c)
+	if !ok {
+		return
+	}
+	res := DB.Model(&APIKey{}).Where("id = ? AND revoked_at IS NULL", id).Update("revoked_at", gorm.Expr("now()"))
+	if res.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": res.Error.Error()})
+		return
+	}
+	if res.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "api key not found"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// findAPIKey ищет действующий ключ и отмечает его использование
+func findAPIKey(key string) (*APIKey, error) {
+	if !strings.HasPrefix(key, apiKeyPrefix) {
+		return nil, errInvalidAPIKey
+	}
+	var obj APIKey
+	err := DB.Where("key_hash = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > now())", hashAPIKey(key)).
+		First(&obj).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errInvalidAPIKey
+	}
+	if err != nil {
+		return nil, err
+	}
+	if obj.LastUsedAt == nil || time.Since(*obj.LastUsedAt) > lastUsedInterval {
+		if err := DB.Model(&obj).UpdateColumn("last_used_at", gorm.Expr("now()")).Error; err != nil {
+			return nil, err
+		}
+	}
+	return &obj, nil
+}
@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+
+	"bd_back_for_translate_app/audio"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var AudioStore audio.Store
+
+// audioTranslation — перевод с озвучкой: в строке хранится только ключ клипа
+type audioTranslation interface {
+	audioFields() (id **string, data *[]byte)
+}
+
+// lockAudio блокирует клипы ids до конца транзакции tx. Под этой блокировкой releaseAudio
+// проверяет ссылки и удаляет клип, а запись кладёт клип в хранилище и сохраняет строку со ссылкой,
+// поэтому клип не пропадёт между Put и коммитом. Ключи блокируются по порядку, чтобы не было взаимных блокировок
+func lockAudio(tx *gorm.DB, ids ...string) error {
+	for _, id := range slices.Compact(slices.Sorted(slices.Values(ids))) {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", id).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// storeAudio кладёт клип в хранилище под lockAudio и возвращает его ключ; nil — клипа нет или
+// хранилище не ответило. Строку со ссылкой на клип нужно записать в той же транзакции tx
+func storeAudio(tx *gorm.DB, wav []byte) (*string, error) {
+	if len(wav) == 0 || AudioStore == nil {
+		return nil, nil
+	}
+	if err := lockAudio(tx, audio.ID(wav)); err != nil {
+		return nil, err
+	}
+	id, err := AudioStore.Put(context.Background(), wav)
+	if err != nil {
+		log.Printf("[audio] store error: %v", err)
+		return nil, nil
+	}
+	return &id, nil
+}
+
+// storeTranslationAudio сохраняет синтезированные клипы переводов (поле Audio) и проставляет их ключи
+func storeTranslationAudio[T any, P interface {
+	*T
+	audioTranslation
+}](tx *gorm.DB, list []T) error {
+	ids := make([]string, 0, len(list))
+	for i := range list {
+		if _, data := P(&list[i]).audioFields(); len(*data) > 0 {
+			ids = append(ids, audio.ID(*data))
+		}
+	}
+	if err := lockAudio(tx, ids...); err != nil {
+		return err
+	}
+	for i := range list {
+		id, data := P(&list[i]).audioFields()
+		stored, err := storeAudio(tx, *data)
+		if err != nil {
+			return err
+		}
+		*id = stored
+	}
+	return nil
+}
+
+// releaseAudio удаляет из хранилища клипы, на которые больше не ссылается ни один перевод.
+// Клипы адресуются по содержимому и могут быть общими, поэтому ссылки проверяются заново
+// под lockAudio: параллельная запись того же клипа дождётся удаления и положит его снова
+func releaseAudio(ids []string) {
+	if AudioStore == nil {
+		return
+	}
+	for _, id := range slices.Compact(slices.Sorted(slices.Values(ids))) {
+		err := DB.Transaction(func(tx *gorm.DB) error {
+			if err := lockAudio(tx, id); err != nil {
+				return err
+			}
+			var used bool
+			if err := tx.Raw(`SELECT EXISTS (SELECT 1 FROM word_translations WHERE audio_id = ?)
+			OR EXISTS (SELECT 1 FROM text_translations WHERE audio_id = ?)`, id, id).Scan(&used).Error; err != nil {
+				return err
+			}
+			if used {
+				return nil
+			}
+			if err := AudioStore.Delete(context.Background(), id); err != nil && !errors.Is(err, audio.ErrNotFound) {
+				return err
+			}
+			return nil
+		})
+		if err != nil {
+			log.Printf("[audio] release %s error: %v", id, err)
+		}
+	}
+}
+
+// audioIDs — ключи клипов переводов
+func audioIDs[T any, P interface {
+	*T
+	audioTranslation
+}](list []T) []string {
+	var ids []string
+	for i := range list {
+		if id, _ := P(&list[i]).audioFields(); *id != nil {
+			ids = append(ids, **id)
+		}
+	}
+	return ids
+}
+
+// loadAudio подгружает содержимое клипов из хранилища для ответа API
+func loadAudio[T any, P interface {
+	*T
+	audioTranslation
+}](list []T) {
+	if AudioStore == nil {
+		return
+	}
+	for i := range list {
+		id, data := P(&list[i]).audioFields()
+		if *id == nil {
+			continue
+		}
+		wav, err := audio.ReadAll(context.Background(), AudioStore, **id)
+		if err != nil {
+			log.Printf("[audio] load %s error: %v", **id, err)
+			continue
+		}
+		*data = wav
+	}
+}
+
+// GetWordAudio отдаёт озвучку слова на языке :lang (поддерживает Range и If-None-Match)
+func GetWordAudio(c *gin.Context) {
+	id, ok := getID(c)
+	if !ok {
+		return
+	}
+	var t WordTranslation
+	err := visibleTo(c, "words", DB.Select("word_translations.audio_id")).
+		Joins("JOIN words ON words.id = word_translations.word_id AND words.deleted_at IS NULL").
+		Where("word_id = ? AND lang = ?", id, c.Param("lang")).First(&t).Error
+	serveAudio(c, t.AudioID, err)
+}
+
+// GetTextAudio отдаёт озвучку текста на языке :lang (поддерживает Range и If-None-Match)
+func GetTextAudio(c *gin.Context) {
+	id, ok := getID(c)
+	if !ok {
+		return
+	}
+	var t TextTranslation
+	err := visibleTo(c, "texts", DB.Select("text_translations.audio_id")).
+		Joins("JOIN texts ON texts.id = text_translations.text_id AND texts.deleted_at IS NULL").
+		Where("text_id = ? AND lang = ?", id, c.Param("lang")).First(&t).Error
+	serveAudio(c, t.AudioID, err)
+}
+
+// serveAudio стримит клип из хранилища. Ключ клипа — хэш содержимого,
+// поэтому он же служит сильным ETag; Range и условные запросы обрабатывает http.ServeContent
+func serveAudio(c *gin.Context, audioID *string, lookupErr error) {
+	if lookupErr != nil && !errors.Is(lookupErr, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": lookupErr.Error()})
+		return
+	}
+	if lookupErr != nil || audioID == nil || AudioStore == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "audio not found"})
+		return
+	}
+
+	obj, err := AudioStore.Open(c.Request.Context(), *audioID)
+	if errors.Is(err, audio.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "audio not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer obj.Close()
+
+	head := make([]byte, 12)
+	n, _ := io.ReadFull(obj, head)
+	if _, err := obj.Seek(0, io.SeekStart); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Type", audio.ContentType(head[:n]))
+	c.Header("ETag", `"`+obj.ID+`"`)
+	// адрес не content-addressed: после правки слова клип меняется, поэтому только ревалидация
+	c.Header("Cache-Control", "no-cache")
+	http.ServeContent(c.Writer, c.Request, "", obj.ModTime, obj)
+}
@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// audioJob — озвучить переводы записи, у которых ещё нет клипа
+type audioJob struct {
+	name string // words или texts
+	id   int
+}
+
+// audioQueue разбирает фоновый обработчик из StartAudioQueue; до запуска очереди задания отбрасываются
+var audioQueue chan audioJob
+
+// StartAudioQueue запускает фоновую озвучку. AUDIO_QUEUE_SIZE — сколько заданий
+// может ждать (по умолчанию 10000); не поместившиеся озвучат при следующем старте
+// GenerateMissingWordAudio и GenerateMissingTextAudio
+func StartAudioQueue() error {
+	size := 10000
+	if v := os.Getenv("AUDIO_QUEUE_SIZE"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return fmt.Errorf("AUDIO_QUEUE_SIZE must be a positive integer, got %q", v)
+		}
+		size = n
+	}
+	audioQueue = make(chan audioJob, size)
+	go func() {
+		for job := range audioQueue {
+			if err := generateAudio(job); err != nil {
+				log.Printf("[audio queue] %s id=%d err=%v", job.name, job.id, err)
+			}
+		}
+	}()
+	return nil
+}
+
+// enqueueAudio ставит записи в очередь озвучки; не блокируется, если очередь заполнена:
+// пропущенные записи остаются без клипа до батча на старте
+func enqueueAudio(name string, ids []int) {
+	if audioQueue == nil || TtsClient == nil {
+		return
+	}
+	for _, id := range ids {
+		select {
+		case audioQueue <- audioJob{name: name, id: id}:
+		default:
+			log.Printf("[audio queue] full, %s id=%d skipped", name, id)
+		}
+	}
+}
+
+// generateAudio озвучивает переводы без клипа. Версия записи не меняется:
+// клип выводится из текста, как и при батче на старте
+func generateAudio(job audioJob) error {
+	switch job.name {
+	case "words":
+		var list []WordTranslation
+		if err := DB.Where("word_id = ? AND audio_id IS NULL", job.id).Find(&list).Error; err != nil {
+			return err
+		}
+		for _, t := range list {
+			if err := attachAudio(&WordTranslation{}, "word_id", job.id, t.Lang, synthesize(job.id, t.Lang, t.Word)); err != nil {
+				return err
+			}
+		}
+	case "texts":
+		var list []TextTranslation
+		if err := DB.Where("text_id = ? AND audio_id IS NULL", job.id).Find(&list).Error; err != nil {
+			return err
+		}
+		for _, t := range list {
+			if err := attachAudio(&TextTranslation{}, "text_id", job.id, t.Lang, synthesize(job.id, t.Lang, t.Content)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// attachAudio кладёт клип в хранилище и ссылается на него из перевода fk = id на языке lang
+// одной транзакцией, чтобы releaseAudio не удалил клип между ними; пустой wav — ничего не делает
+func attachAudio(model any, fk string, id int, lang string, wav []byte) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		audioID, err := storeAudio(tx, wav)
+		if err != nil || audioID == nil {
+			return err
+		}
+		return tx.Model(model).Where(fk+" = ? AND lang = ?", id, lang).UpdateColumn("audio_id", *audioID).Error
+	})
+}
+
+// RegenerateAudio — POST /api/<words|texts>/:id/audio/regenerate?lang=: сбрасывает клипы
+// записи (или одного языка) и ставит её в очередь озвучки. Ответ 202 — синтез идёт в фоне
+func RegenerateAudio(name string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := getID(c)
+		if !ok {
+			return
+		}
+		lang := c.Query("lang")
+		if !validLanguage(c, lang) {
+			return
+		}
+		if audioQueue == nil || TtsClient == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "speech synthesis is not available"})
+			return
+		}
+		t := entities[name]
+		var n int64
+		if err := DB.Table(t.table).Where("id = ? AND deleted_at IS NULL", id).Count(&n).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if n == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "record not found"})
+			return
+		}
+
+		var released []string
+		err := DB.Transaction(func(tx *gorm.DB) error {
+			q := tx.Table(t.audioTable).Where(t.trFK+" = ? AND audio_id IS NOT NULL", id)
+			if lang != "" {
+				q = q.Where("lang = ?", lang)
+			}
+			if err := q.Session(&gorm.Session{}).Pluck("audio_id", &released).Error; err != nil {
+				return err
+			}
+			return q.Update("audio_id", nil).Error
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		releaseAudio(released)
+		enqueueAudio(name, []int{id})
+		c.JSON(http.StatusAccepted, gin.H{"queued": true})
+	}
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+
+	"bd_back_for_translate_app/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Ключи контекста gin: проверенный access-токен или API-ключ запроса
+const (
+	claimsKey = "auth.claims"
+	apiKeyKey = "auth.api_key"
+)
+
+// Authenticate пропускает запрос только с действительными учётными данными в Authorization:
+// «Bearer <access-токен>» пользователя (проверяется по подписи, без обращения к БД)
+// или «ApiKey <ключ>» для скриптов
+func Authenticate(c *gin.Context) {
+	header := c.GetHeader("Authorization")
+	if key, ok := strings.CutPrefix(header, "ApiKey "); ok {
+		obj, err := findAPIKey(key)
+		if errors.Is(err, errInvalidAPIKey) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error(), "code": "invalid_api_key"})
+			return
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.Set(apiKeyKey, obj)
+		c.Next()
+		return
+	}
+
+	token, ok := strings.CutPrefix(header, "Bearer ")
+	if !ok || token == "" {
+		c.Header("WWW-Authenticate", "Bearer")
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+		return
+	}
+	claims, err := auth.Verify(token, auth.AccessToken)
+	if err != nil {
+		code := "invalid_token"
+		if errors.Is(err, auth.ErrExpiredToken) {
+			code = "token_expired"
+		}
+		c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error(), "code": code})
+		return
+	}
+	c.Set(claimsKey, claims)
+	c.Next()
+}
+
+// Require пропускает запрос, если у пользователя (по роли) или у ключа есть право scope;
+// ставится после Authenticate
+func Require(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !allowed(c, scope) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "forbidden", "scope": scope})
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireUser — маршруты от имени пользователя (свой профиль, своё обучение): API-ключи не подходят
+func RequireUser(c *gin.Context) {
+	if currentUser(c) == nil {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "user account required"})
+		return
+	}
+	c.Next()
+}
+
+func allowed(c *gin.Context, scope string) bool {
+	if claims := currentUser(c); claims != nil {
+		return auth.RoleAllows(claims.Role, scope)
+	}
+	if key := currentAPIKey(c); key != nil {
+		return key.allows(scope)
+	}
+	return false
+}
+
+// currentUser — владелец токена запроса; nil на открытых маршрутах и для API-ключей
+func currentUser(c *gin.Context) *auth.Claims {
+	if v, ok := c.Get(claimsKey); ok {
+		return v.(*auth.Claims)
+	}
+	return nil
+}
+
+// currentAPIKey — ключ, которым подписан запрос
+func currentAPIKey(c *gin.Context) *APIKey {
+	if v, ok := c.Get(apiKeyKey); ok {
+		return v.(*APIKey)
+	}
+	return nil
+}
+
+// isEditor — видит ли клиент неопубликованное: редакторы, администраторы и ключи с правом на запись
+func isEditor(c *gin.Context) bool {
+	if claims := currentUser(c); claims != nil {
+		return claims.Role == auth.RoleEditor || claims.Role == auth.RoleAdmin
+	}
+	if key := currentAPIKey(c); key != nil {
+		return slices.ContainsFunc(key.Scopes, auth.Editorial)
+	}
+	return false
+}
@@ -2,6 +2,8 @@ package handlers
 
 import (
 	"log"
+
+	"bd_back_for_translate_app/languages"
 )
 
 func GenerateMissingWordAudio() error {
@@ -9,48 +11,56 @@ func GenerateMissingWordAudio() error {
 		return nil
 	}
 
-	type batchWord struct {
-		ID              int
-		TranscriptionRu string
-		TranscriptionEn string
-		TranscriptionDe string
+	var list []WordTranslation
+
+	if err := DB.
+		Select("word_id, lang, transcription").
+		Where("audio_id IS NULL AND transcription <> '' AND lang IN ?", languages.Codes()).
+		Where("word_id IN (SELECT id FROM words WHERE deleted_at IS NULL)").
+		Find(&list).Error; err != nil {
+		return err
+	}
+
+	for _, t := range list {
+		wav, err := TtsClient.Synthesize(t.Transcription, t.Lang)
+		if err != nil {
+			log.Printf("[batch] synth id=%d lang=%s err=%v", t.WordID, t.Lang, err)
+			continue
+		}
+
+		if err := attachAudio(&WordTranslation{}, "word_id", t.WordID, t.Lang, wav); err != nil {
+			log.Printf("[batch] update id=%d err=%v", t.WordID, err)
+		} else {
+			log.Printf("[batch] id=%d %s OK", t.WordID, t.Lang)
+		}
 	}
 
-	var list []batchWord
+	return nil
+}
+
+// GenerateMissingTextAudio озвучивает переводы текстов без клипа, в том числе
+// не поместившиеся в очередь озвучки
+func GenerateMissingTextAudio() error {
+	if TtsClient == nil {
+		return nil
+	}
 
+	var ids []int
 	if err := DB.
-		Model(&Word{}).
-		Select("id, transcription_ru, transcription_en, transcription_de").
-		Where("audio_ru IS NULL OR audio_en IS NULL OR audio_de IS NULL").
-		Scan(&list).Error; err != nil {
+		Model(&TextTranslation{}).
+		Distinct("text_id").
+		Where("audio_id IS NULL AND content <> '' AND lang IN ?", languages.Codes()).
+		Where("text_id IN (SELECT id FROM texts WHERE deleted_at IS NULL)").
+		Pluck("text_id", &ids).Error; err != nil {
 		return err
 	}
 
-	for _, w := range list {
-
-		update := func(col, ipa, lang string) {
-			if ipa == "" {
-				return
-			}
-			wav, err := TtsClient.Synthesize(ipa, lang)
-			if err != nil {
-				log.Printf("[batch] synth id=%d lang=%s err=%v", w.ID, lang, err)
-				return
-			}
-
-			if err := DB.
-				Model(&Word{}).
-				Where("id = ?", w.ID).
-				UpdateColumn(col, wav).Error; err != nil {
-				log.Printf("[batch] update id=%d err=%v", w.ID, err)
-			} else {
-				log.Printf("[batch] id=%d %s OK", w.ID, lang)
-			}
+	for _, id := range ids {
+		if err := generateAudio(audioJob{name: "texts", id: id}); err != nil {
+			log.Printf("[batch] text id=%d err=%v", id, err)
+		} else {
+			log.Printf("[batch] text id=%d OK", id)
 		}
-
-		update("audio_ru", w.TranscriptionRu, "ru")
-		update("audio_en", w.TranscriptionEn, "en")
-		update("audio_de", w.TranscriptionDe, "de")
 	}
 
 	return nil
@@ -0,0 +1,320 @@
+package handlers
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"bd_back_for_translate_app/audio"
+	"bd_back_for_translate_app/languages"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// bundleFormat — версия структуры архива; меняется, когда старые клиенты не смогут его прочитать
+const bundleFormat = 1
+
+// BundleManifest — manifest.json архива: версия, состав и контрольные суммы остальных файлов
+type BundleManifest struct {
+	Format    int                  `json:"format"`
+	Version   string               `json:"version"`
+	CreatedAt time.Time            `json:"created_at"`
+	Languages []languages.Language `json:"languages"`
+	Counts    map[string]int       `json:"counts"`
+	Files     []BundleFile         `json:"files"`
+}
+
+type BundleFile struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// BundleAudio — строка audio.json: клип перевода записи на языке lang
+type BundleAudio struct {
+	Type string `json:"type"`
+	ID   int    `json:"id"`
+	Lang string `json:"lang"`
+	File string `json:"file"`
+}
+
+// bundleDir — каталог архивов, BUNDLE_DIR (по умолчанию ./bundles)
+func bundleDir() string {
+	if v := os.Getenv("BUNDLE_DIR"); v != "" {
+		return v
+	}
+	return "./bundles"
+}
+
+// bundleWriter пишет файлы в zip и запоминает их размер и SHA-256 для манифеста
+type bundleWriter struct {
+	zw    *zip.Writer
+	files []BundleFile
+}
+
+func (w *bundleWriter) add(path string, data []byte) error {
+	method := zip.Deflate
+	if strings.HasPrefix(path, "audio/") {
+		// аудио уже сжато, повторное сжатие только тратит время
+		method = zip.Store
+	}
+	f, err := w.zw.CreateHeader(&zip.FileHeader{Name: path, Method: method, Modified: time.Now()})
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		return err
+	}
+	sum := sha256.Sum256(data)
+	w.files = append(w.files, BundleFile{Path: path, Size: int64(len(data)), SHA256: hex.EncodeToString(sum[:])})
+	return nil
+}
+
+func (w *bundleWriter) addJSON(path string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return w.add(path, data)
+}
+
+// BuildBundle собирает офлайн-архив в BUNDLE_DIR и возвращает путь к нему.
+// Все данные читаются одним снимком БД, поэтому архив согласован.
+// Старые архивы сверх BUNDLE_KEEP (по умолчанию 3) удаляются
+func BuildBundle() (string, error) {
+	dir := bundleDir()
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	now := time.Now().UTC()
+	version := now.Format("20060102T150405Z")
+
+	tmp, err := os.CreateTemp(dir, ".bundle-*.zip")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	w := &bundleWriter{zw: zip.NewWriter(tmp)}
+	counts := map[string]int{}
+	err = DB.Transaction(func(tx *gorm.DB) error {
+		return writeBundle(tx, w, counts)
+	}, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return "", err
+	}
+
+	manifest := BundleManifest{
+		Format:    bundleFormat,
+		Version:   version,
+		CreatedAt: now,
+		Languages: languages.List(),
+		Counts:    counts,
+		Files:     w.files,
+	}
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	f, err := w.zw.Create("manifest.json")
+	if err != nil {
+		return "", err
+	}
+	if _, err := f.Write(data); err != nil {
+		return "", err
+	}
+	if err := w.zw.Close(); err != nil {
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(dir, "bundle-"+version+".zip")
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", err
+	}
+	pruneBundles(dir)
+	return path, nil
+}
+
+// writeBundle выгружает опубликованное содержимое в том же JSON, что отдаёт API
+func writeBundle(tx *gorm.DB, w *bundleWriter, counts map[string]int) error {
+	var categories []Category
+	if err := tx.Preload("Translations").Order("id").Find(&categories).Error; err != nil {
+		return err
+	}
+	var words []Word
+	if err := publishedOnly("words", tx.Preload("Translations")).Order("id").Find(&words).Error; err != nil {
+		return err
+	}
+	var texts []Text
+	if err := publishedOnly("texts", tx.Preload("Translations")).Order("id").Find(&texts).Error; err != nil {
+		return err
+	}
+	var grammarIDs []int
+	if err := publishedOnly("grammars", tx.Model(&Grammars{})).Order("id").Pluck("id", &grammarIDs).Error; err != nil {
+		return err
+	}
+	grammars := make([]*GrammarDocument, 0, len(grammarIDs))
+	for _, id := range grammarIDs {
+		doc, err := loadGrammarDocument(tx, id)
+		if err != nil {
+			return err
+		}
+		grammars = append(grammars, doc)
+	}
+
+	if err := w.addJSON("categories.json", categories); err != nil {
+		return err
+	}
+	if err := w.addJSON("words.json", words); err != nil {
+		return err
+	}
+	if err := w.addJSON("texts.json", texts); err != nil {
+		return err
+	}
+	if err := w.addJSON("grammars.json", grammars); err != nil {
+		return err
+	}
+	counts["categories"] = len(categories)
+	counts["words"] = len(words)
+	counts["texts"] = len(texts)
+	counts["grammars"] = len(grammars)
+
+	// клипы кладутся один раз, даже если их делят несколько переводов
+	var index []BundleAudio
+	files := map[string]string{}
+	addClip := func(name string, id int, lang string, audioID *string) error {
+		if audioID == nil || AudioStore == nil {
+			return nil
+		}
+		file, ok := files[*audioID]
+		if !ok {
+			data, err := audio.ReadAll(context.Background(), AudioStore, *audioID)
+			if errors.Is(err, audio.ErrNotFound) {
+				log.Printf("[bundle] %s id=%d %s: clip %s is missing", name, id, lang, *audioID)
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			file = "audio/" + *audioID + audioExt(data)
+			if err := w.add(file, data); err != nil {
+				return err
+			}
+			files[*audioID] = file
+		}
+		index = append(index, BundleAudio{Type: name, ID: id, Lang: lang, File: file})
+		return nil
+	}
+	for _, wd := range words {
+		for _, t := range wd.Translations {
+			if err := addClip("words", wd.ID, t.Lang, t.AudioID); err != nil {
+				return err
+			}
+		}
+	}
+	for _, text := range texts {
+		for _, t := range text.Translations {
+			if err := addClip("texts", text.ID, t.Lang, t.AudioID); err != nil {
+				return err
+			}
+		}
+	}
+	counts["audio"] = len(files)
+	if index == nil {
+		index = []BundleAudio{}
+	}
+	return w.addJSON("audio.json", index)
+}
+
+func audioExt(data []byte) string {
+	switch audio.ContentType(data) {
+	case "audio/wav":
+		return ".wav"
+	case "audio/ogg":
+		return ".ogg"
+	case "audio/flac":
+		return ".flac"
+	case "audio/mpeg":
+		return ".mp3"
+	}
+	return ""
+}
+
+// bundleFiles — архивы в dir от нового к старому (версия в имени сортируется как строка)
+func bundleFiles(dir string) ([]string, error) {
+	list, err := filepath.Glob(filepath.Join(dir, "bundle-*.zip"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(list)))
+	return list, nil
+}
+
+func pruneBundles(dir string) {
+	keep := 3
+	if v, err := strconv.Atoi(os.Getenv("BUNDLE_KEEP")); err == nil && v > 0 {
+		keep = v
+	}
+	list, err := bundleFiles(dir)
+	if err != nil {
+		log.Printf("[bundle] prune: %v", err)
+		return
+	}
+	for i := keep; i < len(list); i++ {
+		if err := os.Remove(list[i]); err != nil {
+			log.Printf("[bundle] prune %s: %v", list[i], err)
+		}
+	}
+}
+
+// GetLatestBundle — GET /api/bundles/latest: последний собранный архив.
+// Версия архива служит ETag, поддерживаются Range и If-None-Match
+func GetLatestBundle(c *gin.Context) {
+	list, err := bundleFiles(bundleDir())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(list) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no bundle built yet"})
+		return
+	}
+	f, err := os.Open(list[0])
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	name := filepath.Base(list[0])
+	version := strings.TrimSuffix(strings.TrimPrefix(name, "bundle-"), ".zip")
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+	c.Header("ETag", `"`+version+`"`)
+	c.Header("X-Bundle-Version", version)
+	c.Header("Cache-Control", "no-cache")
+	http.ServeContent(c.Writer, c.Request, name, info.ModTime(), f)
+}
@@ -1,41 +1,110 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
 type Category struct {
-	ID         int    `gorm:"primaryKey;column:id"    json:"id"`
-	NameEn     string `gorm:"column:name_en"          json:"name_en"`
-	NameRu     string `gorm:"column:name_ru"          json:"name_ru"`
-	NameDe     string `gorm:"column:name_de"          json:"name_de"`
-	TypeName   string `gorm:"column:type_name"        json:"type_name"`
-	TypeNameRu string `gorm:"column:type_name_ru"     json:"type_name_ru"`
-	TypeNameDe string `gorm:"column:type_name_de"     json:"type_name_de"`
-	Entity     string `gorm:"column:entity"           json:"entity"`
+	ID           int                   `gorm:"primaryKey;column:id"    json:"id"`
+	Entity       string                `gorm:"column:entity"           json:"entity"`
+	DeletedAt    gorm.DeletedAt        `gorm:"column:deleted_at"       json:"-"`
+	Version      int                   `gorm:"column:version;default:1" json:"-"`
+	UpdatedAt    time.Time             `gorm:"column:updated_at"        json:"updated_at"`
+	Translations []CategoryTranslation `gorm:"foreignKey:CategoryID"   json:"-"`
+}
+
+type CategoryTranslation struct {
+	CategoryID int    `gorm:"primaryKey;column:category_id"`
+	Lang       string `gorm:"primaryKey;column:lang"`
+	Name       string `gorm:"column:name"`
+	TypeName   string `gorm:"column:type_name"`
+}
+
+func (t *CategoryTranslation) language() string        { return t.Lang }
+func (t *CategoryTranslation) setLanguage(lang string) { t.Lang = lang }
+func (t *CategoryTranslation) setOwner(id int)         { t.CategoryID = id }
+func (t *CategoryTranslation) jsonFields() map[string]any {
+	return map[string]any{
+		"name":      &t.Name,
+		"type_name": &t.TypeName,
+	}
+}
+
+// английское название типа исторически отдаётся без суффикса языка
+var categoryJSONAliases = map[string]string{"type_name": "type_name_en"}
+
+type categoryJSON Category
+
+func (c Category) MarshalJSON() ([]byte, error) {
+	return marshalTranslated(categoryJSON(c), c.Translations, categoryJSONAliases)
+}
+
+func (c *Category) UnmarshalJSON(data []byte) error {
+	return unmarshalTranslated(data, (*categoryJSON)(c), &c.Translations, categoryJSONAliases)
+}
+
+var categoryList = &listSpec{
+	table: "categories",
+	sorts: map[string]sortField{
+		"entity": {expr: "categories.entity", kind: sortText},
+	},
+	translated: []translatedSort{
+		{prefix: "name", table: "category_translations", fk: "category_id", column: "name"},
+	},
+	filters: map[string]listFilter{
+		"entity":   textFilter("categories.entity"),
+		"language": languageFilter("categories", "category_translations", "category_id", "name"),
+	},
 }
 
 func GetCategories(c *gin.Context) {
 	var list []Category
-	if err := DB.Find(&list).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if !findPage(c, categoryList, DB, func(db *gorm.DB) *gorm.DB { return db.Preload("Translations") }, &list) {
 		return
 	}
 	c.JSON(http.StatusOK, list)
 }
 
+func GetCategory(c *gin.Context) {
+	id, ok := getID(c)
+	if !ok {
+		return
+	}
+	var obj Category
+	if err := DB.Preload("Translations").First(&obj, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "category not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	if setETag(c, versionETag(obj.Version)) {
+		return
+	}
+	c.JSON(http.StatusOK, obj)
+}
+
 func CreateCategory(c *gin.Context) {
 	var obj Category
 	if !bindJSON(c, &obj) {
 		return
 	}
-	if err := DB.Create(&obj).Error; err != nil {
+	if !validate(c, obj) {
+		return
+	}
+	if err := createRecorded(actorOf(c), "categories", &obj, &obj.ID); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	c.Header("ETag", versionETag(obj.Version))
 	c.JSON(http.StatusCreated, obj)
 }
 
@@ -46,7 +115,7 @@ func UpdateCategory(c *gin.Context) {
 	}
 
 	var obj Category
-	if err := DB.First(&obj, id).Error; err != nil {
+	if err := DB.Preload("Translations").First(&obj, id).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, gin.H{"error": "category not found"})
 		} else {
@@ -54,26 +123,114 @@ func UpdateCategory(c *gin.Context) {
 		}
 		return
 	}
+	version := obj.Version
+	if !checkIfMatch(c, versionETag(version), currentItem("categories", id)) {
+		return
+	}
 
 	if !bindJSON(c, &obj) {
 		return
 	}
+	v := newValidator()
+	obj.validate(v)
+	v.categoryUsage(id, obj.Entity)
+	if !v.respond(c) {
+		return
+	}
 	obj.ID = id
+	obj.Version = version + 1
 
-	if err := DB.Save(&obj).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := saveTranslated(actorOf(c), &obj, "categories", id, version, obj.Translations); err != nil {
+		saveFailed(c, err, currentItem("categories", id))
 		return
 	}
+	c.Header("ETag", versionETag(obj.Version))
 	c.JSON(http.StatusOK, obj)
 }
 
+var errCategoryInUse = errors.New("category is in use")
+
+// DeleteCategory — DELETE /api/categories/:id[?reassign_to=]. Категорию, на которую
+// ссылаются слова или тексты, удалить нельзя (409), пока их не перенести в другую
+// категорию той же сущности через reassign_to
 func DeleteCategory(c *gin.Context) {
 	id, ok := getID(c)
 	if !ok {
 		return
 	}
-	if err := DB.Delete(&Category{}, id).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	reassignTo := 0
+	if v := c.Query("reassign_to"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n == id {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid reassign_to"})
+			return
+		}
+		reassignTo = n
+	}
+
+	var obj Category
+	if err := DB.First(&obj, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Status(http.StatusNoContent)
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	if !checkIfMatch(c, versionETag(obj.Version), currentItem("categories", id)) {
+		return
+	}
+	checked := c.GetHeader("If-Match") != ""
+	if reassignTo != 0 {
+		if !checkRef(c, "reassign_to", "categories", reassignTo, false) {
+			return
+		}
+		var target Category
+		if err := DB.First(&target, reassignTo).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if target.Entity != obj.Entity {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("reassign_to: category entity is %q, expected %q", target.Entity, obj.Entity)})
+			return
+		}
+	}
+
+	var words, texts int64
+	at := time.Now().UTC().Truncate(time.Microsecond)
+	err := withRevision(actorOf(c), "categories", &id, actionDelete, func(tx *gorm.DB) error {
+		if checked {
+			if err := lockVersion(tx, "categories", id, obj.Version); err != nil {
+				return err
+			}
+		}
+		if reassignTo != 0 {
+			// переносятся и записи из корзины, иначе категорию нельзя будет вычистить
+			if err := tx.Unscoped().Model(&Word{}).Where("category_id = ?", id).Update("category_id", reassignTo).Error; err != nil {
+				return err
+			}
+			if err := tx.Unscoped().Model(&Text{}).Where("category_id = ?", id).Update("category_id", reassignTo).Error; err != nil {
+				return err
+			}
+		} else {
+			if err := tx.Model(&Word{}).Where("category_id = ?", id).Count(&words).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&Text{}).Where("category_id = ?", id).Count(&texts).Error; err != nil {
+				return err
+			}
+			if words+texts > 0 {
+				return errCategoryInUse
+			}
+		}
+		return trashRows(tx, "categories", []int{id}, at)
+	})
+	if errors.Is(err, errCategoryInUse) {
+		c.JSON(http.StatusConflict, gin.H{"error": "category is in use, pass reassign_to to move its items", "words": words, "texts": texts})
+		return
+	}
+	if err != nil {
+		saveFailed(c, err, currentItem("categories", id))
 		return
 	}
 	c.Status(http.StatusNoContent)
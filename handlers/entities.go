@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"reflect"
+
+	"gorm.io/gorm"
+)
+
+// entityType — описание сущности API: таблица, связи и как её загрузить.
+// По нему работают корзина, версии, история правок и отчёт о висячих ссылках
+type entityType struct {
+	table   string
+	trFK    string // колонка таблицы переводов со ссылкой на сущность
+	parent  string // тип родителя: пока он в корзине, запись восстановить нельзя
+	fk      string // колонка со ссылкой на родителя
+	cascade bool   // уходит в корзину и возвращается вместе с родителем
+	// условие, без которого строку нельзя удалить окончательно (на неё ещё ссылаются)
+	purgeGuard string
+	audioTable string // таблица переводов с клипами; клипы освобождаются при очистке
+	model      func() any
+	load       func(db *gorm.DB, ids []int) (map[int]any, error)
+}
+
+var entities = map[string]*entityType{
+	"categories": {table: "categories", trFK: "category_id",
+		model: newModel[Category], load: loadEntities[Category],
+		purgeGuard: "NOT EXISTS (SELECT 1 FROM words WHERE category_id = categories.id)" +
+			" AND NOT EXISTS (SELECT 1 FROM texts WHERE category_id = categories.id)"},
+	"words": {table: "words", trFK: "word_id", parent: "categories", fk: "category_id",
+		model: newModel[Word], load: loadEntities[Word], audioTable: "word_translations"},
+	"texts": {table: "texts", trFK: "text_id", parent: "categories", fk: "category_id",
+		model: newModel[Text], load: loadEntities[Text], audioTable: "text_translations"},
+	"grammars": {table: "grammars", trFK: "grammar_id",
+		model: newModel[Grammars], load: loadEntities[Grammars]},
+	"grammar_rules": {table: "grammar_rules", trFK: "rule_id", parent: "grammars", fk: "grammar_id", cascade: true,
+		model: newModel[GrammarRules], load: loadEntities[GrammarRules]},
+	"grammar_examples": {table: "grammar_examples", trFK: "example_id", parent: "grammar_rules", fk: "rule_id", cascade: true,
+		model: newModel[GrammarExamples], load: loadEntities[GrammarExamples]},
+	"grammar_exceptions": {table: "grammar_exceptions", trFK: "exception_id", parent: "grammar_rules", fk: "rule_id", cascade: true,
+		model: newModel[GrammarExceptions], load: loadEntities[GrammarExceptions]},
+}
+
+func newModel[T any]() any { return new(T) }
+
+// loadEntities загружает записи с переводами по id, включая удалённые в корзину
+func loadEntities[T any](db *gorm.DB, ids []int) (map[int]any, error) {
+	var list []T
+	if err := db.Unscoped().Preload("Translations").Find(&list, ids).Error; err != nil {
+		return nil, err
+	}
+	out := make(map[int]any, len(list))
+	for _, item := range list {
+		out[int(reflect.ValueOf(item).FieldByName("ID").Int())] = item
+	}
+	return out, nil
+}
@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"bd_back_for_translate_app/languages"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// fieldSpec описывает поля сущности для проекции ?fields=
+type fieldSpec struct {
+	table      string
+	columns    map[string]string // JSON-ключ → колонка сущности
+	trFK       string            // колонка таблицы переводов со ссылкой на сущность
+	translated map[string]string // префикс JSON-ключа перевода → колонка таблицы переводов
+	audio      string            // префикс поля с озвучкой ("" — озвучки нет)
+}
+
+// projection — что выбирать из БД и какие ключи отдавать в ответе
+type projection struct {
+	columns   []string        // колонки сущности; nil — все
+	preload   bool            // нужны ли переводы
+	trColumns []string        // колонки переводов; nil — все
+	langs     []string        // языки переводов; nil — все
+	audio     bool            // загружать ли клипы из хранилища
+	keys      map[string]bool // ключи ответа; nil — все (кроме audio_*, если audio=false)
+	spec      *fieldSpec
+}
+
+// parseFields разбирает ?fields= и ?include_audio=; includeAudio — значение по умолчанию
+func parseFields(c *gin.Context, spec *fieldSpec, includeAudio bool) (*projection, bool) {
+	p, err := newProjection(c.Query("fields"), c.Query("include_audio"), spec, includeAudio)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return p, true
+}
+
+func newProjection(fields, includeAudioParam string, spec *fieldSpec, includeAudio bool) (*projection, error) {
+	if includeAudioParam != "" {
+		v, err := strconv.ParseBool(includeAudioParam)
+		if err != nil {
+			return nil, fmt.Errorf("include_audio must be true or false")
+		}
+		includeAudio = v
+	}
+	p := &projection{spec: spec, preload: true}
+
+	if fields == "" {
+		p.audio = includeAudio && spec.audio != ""
+		if !p.audio && spec.audio != "" {
+			// все колонки переводов, кроме ссылки на клип
+			p.trColumns = []string{spec.trFK, "lang"}
+			for prefix, col := range spec.translated {
+				if prefix != spec.audio {
+					p.trColumns = append(p.trColumns, col)
+				}
+			}
+		}
+		return p, nil
+	}
+
+	p.keys = map[string]bool{"id": true}
+	// версия нужна для ETag, в JSON она не попадает
+	p.columns = []string{spec.table + ".id", spec.table + ".version"}
+	p.preload = false
+	trCols := map[string]bool{}
+	langs := map[string]bool{}
+
+	for _, name := range strings.Split(fields, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" || p.keys[name] {
+			continue
+		}
+		if col, ok := spec.columns[name]; ok {
+			p.keys[name] = true
+			p.columns = append(p.columns, spec.table+"."+col)
+			continue
+		}
+
+		known := false
+		for prefix, col := range spec.translated {
+			lang, ok := strings.CutPrefix(name, prefix+"_")
+			if !ok || !languages.Supported(lang) {
+				continue
+			}
+			known = true
+			p.keys[name] = true
+			p.preload = true
+			trCols[col] = true
+			langs[lang] = true
+			if prefix == spec.audio {
+				p.audio = true
+			}
+			break
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown field %q", name)
+		}
+	}
+
+	if p.preload {
+		p.trColumns = []string{spec.trFK, "lang"}
+		for col := range trCols {
+			p.trColumns = append(p.trColumns, col)
+		}
+		for lang := range langs {
+			p.langs = append(p.langs, lang)
+		}
+	}
+	return p, nil
+}
+
+// apply ограничивает SELECT сущности и переводов выбранными колонками
+func (p *projection) apply(db *gorm.DB) *gorm.DB {
+	if p.columns != nil {
+		db = db.Select(p.columns)
+	}
+	if !p.preload {
+		return db
+	}
+	return db.Preload("Translations", func(tx *gorm.DB) *gorm.DB {
+		if p.trColumns != nil {
+			tx = tx.Select(p.trColumns)
+		}
+		if p.langs != nil {
+			tx = tx.Where("lang IN ?", p.langs)
+		}
+		return tx
+	})
+}
+
+func (p *projection) keep(key string) bool {
+	if p.keys != nil {
+		return p.keys[key]
+	}
+	if !p.audio && p.spec.audio != "" {
+		if lang, ok := strings.CutPrefix(key, p.spec.audio+"_"); ok && languages.Supported(lang) {
+			return false
+		}
+	}
+	return true
+}
+
+// respond отдаёт объект или список, оставляя в JSON только запрошенные ключи
+func (p *projection) respond(c *gin.Context, status int, v any) {
+	if p.keys == nil && (p.audio || p.spec.audio == "") {
+		c.JSON(status, v)
+		return
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	filter := func(m map[string]json.RawMessage) {
+		for k := range m {
+			if !p.keep(k) {
+				delete(m, k)
+			}
+		}
+	}
+
+	if len(data) > 0 && data[0] == '[' {
+		var items []map[string]json.RawMessage
+		if err := json.Unmarshal(data, &items); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		for _, m := range items {
+			filter(m)
+		}
+		c.JSON(status, items)
+		return
+	}
+
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(data, &m); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	filter(m)
+	c.JSON(status, m)
+}
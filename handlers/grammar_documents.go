@@ -0,0 +1,399 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"maps"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GrammarDocument — грамматика целиком: правила, а в них примеры и исключения.
+// В JSON это обычная грамматика с вложенным массивом rules
+type GrammarDocument struct {
+	Grammars
+	Rules []GrammarRuleDocument
+}
+
+// GrammarRuleDocument — правило с вложенными examples и exceptions
+type GrammarRuleDocument struct {
+	GrammarRules
+	Examples   []GrammarExamples
+	Exceptions []GrammarExceptions
+}
+
+func (d GrammarDocument) MarshalJSON() ([]byte, error) {
+	rules := d.Rules
+	if rules == nil {
+		rules = []GrammarRuleDocument{}
+	}
+	return marshalNested(d.Grammars, map[string]any{"rules": rules})
+}
+
+func (d *GrammarDocument) UnmarshalJSON(data []byte) error {
+	if err := d.Grammars.UnmarshalJSON(data); err != nil {
+		return err
+	}
+	var nested struct {
+		Rules []GrammarRuleDocument `json:"rules"`
+	}
+	if err := json.Unmarshal(data, &nested); err != nil {
+		return err
+	}
+	d.Rules = nested.Rules
+	return nil
+}
+
+func (d GrammarRuleDocument) MarshalJSON() ([]byte, error) {
+	examples, exceptions := d.Examples, d.Exceptions
+	if examples == nil {
+		examples = []GrammarExamples{}
+	}
+	if exceptions == nil {
+		exceptions = []GrammarExceptions{}
+	}
+	return marshalNested(d.GrammarRules, map[string]any{"examples": examples, "exceptions": exceptions})
+}
+
+func (d *GrammarRuleDocument) UnmarshalJSON(data []byte) error {
+	if err := d.GrammarRules.UnmarshalJSON(data); err != nil {
+		return err
+	}
+	var nested struct {
+		Examples   []GrammarExamples   `json:"examples"`
+		Exceptions []GrammarExceptions `json:"exceptions"`
+	}
+	if err := json.Unmarshal(data, &nested); err != nil {
+		return err
+	}
+	d.Examples, d.Exceptions = nested.Examples, nested.Exceptions
+	return nil
+}
+
+// marshalNested добавляет к JSON-объекту base вложенные поля
+func marshalNested(base any, nested map[string]any) ([]byte, error) {
+	data, err := json.Marshal(base)
+	if err != nil {
+		return nil, err
+	}
+	out := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, err
+	}
+	for key, v := range nested {
+		if out[key], err = json.Marshal(v); err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(out)
+}
+
+func loadGrammarDocument(db *gorm.DB, id int) (*GrammarDocument, error) {
+	var doc GrammarDocument
+	if err := db.Preload("Translations").First(&doc.Grammars, id).Error; err != nil {
+		return nil, err
+	}
+
+	var rules []GrammarRules
+	if err := db.Preload("Translations").Where("grammar_id = ?", id).Order("id").Find(&rules).Error; err != nil {
+		return nil, err
+	}
+	ruleIDs := make([]int, len(rules))
+	for i, r := range rules {
+		ruleIDs[i] = r.ID
+	}
+	var (
+		examples   []GrammarExamples
+		exceptions []GrammarExceptions
+	)
+	if len(ruleIDs) > 0 {
+		if err := db.Preload("Translations").Where("rule_id IN ?", ruleIDs).Order("id").Find(&examples).Error; err != nil {
+			return nil, err
+		}
+		if err := db.Preload("Translations").Where("rule_id IN ?", ruleIDs).Order("id").Find(&exceptions).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	byRule := make(map[int]*GrammarRuleDocument, len(rules))
+	doc.Rules = make([]GrammarRuleDocument, len(rules))
+	for i, r := range rules {
+		doc.Rules[i] = GrammarRuleDocument{GrammarRules: r}
+		byRule[r.ID] = &doc.Rules[i]
+	}
+	for _, e := range examples {
+		byRule[e.RuleID].Examples = append(byRule[e.RuleID].Examples, e)
+	}
+	for _, e := range exceptions {
+		byRule[e.RuleID].Exceptions = append(byRule[e.RuleID].Exceptions, e)
+	}
+	return &doc, nil
+}
+
+// etag документа меняется при изменении любого элемента дерева
+func (d GrammarDocument) etag() string {
+	h := fnv.New64a()
+	fmt.Fprintf(h, "g%d:%d", d.ID, d.Version)
+	for _, r := range d.Rules {
+		fmt.Fprintf(h, "|r%d:%d", r.ID, r.Version)
+		for _, e := range r.Examples {
+			fmt.Fprintf(h, "|e%d:%d", e.ID, e.Version)
+		}
+		for _, e := range r.Exceptions {
+			fmt.Fprintf(h, "|x%d:%d", e.ID, e.Version)
+		}
+	}
+	return fmt.Sprintf(`"d%x"`, h.Sum64())
+}
+
+// GetGrammarDocument — GET /api/grammars/:id/full
+func GetGrammarDocument(c *gin.Context) {
+	id, ok := getID(c)
+	if !ok {
+		return
+	}
+	var n int64
+	if err := visibleTo(c, "grammars", DB.Model(&Grammars{})).Where("id = ?", id).Count(&n).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if n == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "grammar not found"})
+		return
+	}
+	doc, err := loadGrammarDocument(DB, id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "grammar not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	if setETag(c, doc.etag()) {
+		return
+	}
+	c.JSON(http.StatusOK, doc)
+}
+
+// CreateGrammarDocument — POST /api/grammars/full: новая грамматика вместе с деревом
+func CreateGrammarDocument(c *gin.Context) {
+	var doc GrammarDocument
+	if !bindJSON(c, &doc) {
+		return
+	}
+	doc.ID = 0
+	saveGrammarDocument(c, &doc, nil, http.StatusCreated)
+}
+
+// UpdateGrammarDocument — PUT /api/grammars/:id/full. Дерево заменяется целиком:
+// элементы с id обновляются, без id — создаются, отсутствующие уходят в корзину.
+// Примеры и исключения можно переносить между правилами этой грамматики
+func UpdateGrammarDocument(c *gin.Context) {
+	id, ok := getID(c)
+	if !ok {
+		return
+	}
+	current, err := loadGrammarDocument(DB, id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "grammar not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	if !checkIfMatch(c, current.etag(), currentGrammarDocument(id)) {
+		return
+	}
+	var doc GrammarDocument
+	if !bindJSON(c, &doc) {
+		return
+	}
+	doc.ID = id
+	doc.Version = current.Version
+	saveGrammarDocument(c, &doc, current, http.StatusOK)
+}
+
+func currentGrammarDocument(id int) func() (any, error) {
+	return func() (any, error) {
+		return loadGrammarDocument(DB, id)
+	}
+}
+
+// documentTrashOrder — порядок, в котором saveGrammarDocument убирает в корзину элементы,
+// пропавшие из дерева: сначала дочерние. Корзина правила уносит и его примеры с исключениями,
+// после этого их версия уже не совпала бы и lockVersion ответил бы errStale
+var documentTrashOrder = []string{"grammar_examples", "grammar_exceptions", "grammar_rules"}
+
+// saveGrammarDocument проверяет дерево и сохраняет его одной транзакцией; current — что лежит в БД сейчас.
+// Каждый существующий элемент сохраняется только если его версия не изменилась с момента чтения current
+func saveGrammarDocument(c *gin.Context, doc *GrammarDocument, current *GrammarDocument, status int) {
+	curStatus, curPublishAt := statusDraft, (*time.Time)(nil)
+	if current != nil {
+		curStatus, curPublishAt = current.Status, current.PublishAt
+	}
+	if !validateUnderWorkflow(c, *doc, doc.Status, doc.PublishAt, curStatus, curPublishAt) {
+		return
+	}
+
+	// id → версия элементов, которые сейчас есть в дереве
+	rules, examples, exceptions := map[int]int{}, map[int]int{}, map[int]int{}
+	if current != nil {
+		for _, r := range current.Rules {
+			rules[r.ID] = r.Version
+			for _, e := range r.Examples {
+				examples[e.ID] = e.Version
+			}
+			for _, e := range r.Exceptions {
+				exceptions[e.ID] = e.Version
+			}
+		}
+	}
+	// claim забирает id из known и подставляет в version версию из БД; чужой или повторный id — ошибка
+	claim := func(known map[int]int, id int, version *int, path string) bool {
+		if id == 0 {
+			return true
+		}
+		v, ok := known[id]
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%s: id %d does not belong to this grammar", path, id)})
+			return false
+		}
+		*version = v
+		delete(known, id)
+		return true
+	}
+	for i := range doc.Rules {
+		r := &doc.Rules[i]
+		path := fmt.Sprintf("rules[%d]", i)
+		if !claim(rules, r.ID, &r.Version, path) {
+			return
+		}
+		for j := range r.Examples {
+			e := &r.Examples[j]
+			if !claim(examples, e.ID, &e.Version, fmt.Sprintf("%s.examples[%d]", path, j)) {
+				return
+			}
+		}
+		for j := range r.Exceptions {
+			e := &r.Exceptions[j]
+			if !claim(exceptions, e.ID, &e.Version, fmt.Sprintf("%s.exceptions[%d]", path, j)) {
+				return
+			}
+		}
+	}
+
+	actor := actorOf(c)
+	at := time.Now().UTC().Truncate(time.Microsecond)
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		if err := replaceTranslated(tx, actor, "grammars", &doc.Grammars, &doc.ID, &doc.Version, "grammar_id", doc.Translations); err != nil {
+			return err
+		}
+		for i := range doc.Rules {
+			r := &doc.Rules[i]
+			r.GrammarID = doc.ID
+			if err := replaceTranslated(tx, actor, "grammar_rules", &r.GrammarRules, &r.ID, &r.Version, "rule_id", r.Translations); err != nil {
+				return err
+			}
+			for j := range r.Examples {
+				e := &r.Examples[j]
+				e.RuleID = r.ID
+				if err := replaceTranslated(tx, actor, "grammar_examples", e, &e.ID, &e.Version, "example_id", e.Translations); err != nil {
+					return err
+				}
+			}
+			for j := range r.Exceptions {
+				e := &r.Exceptions[j]
+				e.RuleID = r.ID
+				if err := replaceTranslated(tx, actor, "grammar_exceptions", e, &e.ID, &e.Version, "exception_id", e.Translations); err != nil {
+					return err
+				}
+			}
+		}
+
+		// в картах остались id, которых нет в документе
+		left := map[string]map[int]int{
+			"grammar_examples":   examples,
+			"grammar_exceptions": exceptions,
+			"grammar_rules":      rules,
+		}
+		for _, name := range documentTrashOrder {
+			if len(left[name]) == 0 {
+				continue
+			}
+			ids := make([]int, 0, len(left[name]))
+			before := make(map[int]snapshotJSON, len(left[name]))
+			for _, id := range slices.Sorted(maps.Keys(left[name])) {
+				if err := lockVersion(tx, name, id, left[name][id]); err != nil {
+					return err
+				}
+				snap, err := snapshot(tx, name, id)
+				if err != nil {
+					return err
+				}
+				ids = append(ids, id)
+				before[id] = snap
+			}
+			if err := trashRows(tx, name, ids, at); err != nil {
+				return err
+			}
+			for _, id := range ids {
+				if err := recordRevision(tx, actor, name, id, actionDelete, before[id]); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		saveFailed(c, err, currentGrammarDocument(doc.ID))
+		return
+	}
+
+	saved, err := loadGrammarDocument(DB, doc.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Header("ETag", saved.etag())
+	c.JSON(status, saved)
+}
+
+// replaceTranslated вставляет (id == 0) или обновляет сущность, заменяет её переводы целиком и пишет ревизию.
+// При обновлении version — версия, которую видел клиент; после сохранения она увеличена
+func replaceTranslated[T any, P translationPtr[T]](tx *gorm.DB, actor, name string, obj any, id, version *int, fk string, list []T) error {
+	action := actionCreate
+	var before snapshotJSON
+	if *id == 0 {
+		if err := tx.Omit(clause.Associations).Create(obj).Error; err != nil {
+			return err
+		}
+	} else {
+		var err error
+		if before, err = snapshot(tx, name, *id); err != nil {
+			return err
+		}
+		if err := bumpVersion(tx, name, *id, *version); err != nil {
+			return err
+		}
+		*version++
+		if err := tx.Omit(clause.Associations).Save(obj).Error; err != nil {
+			return err
+		}
+		action = actionUpdate
+	}
+	if err := tx.Where(fk+" = ?", *id).Delete(new(T)).Error; err != nil {
+		return err
+	}
+	if err := saveTranslations[T, P](tx, *id, list); err != nil {
+		return err
+	}
+	return recordRevision(tx, actor, name, *id, action, before)
+}
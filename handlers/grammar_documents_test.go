@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"testing"
+
+	"bd_back_for_translate_app/auth"
+	"bd_back_for_translate_app/database"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// useTestDB подключает handlers к TEST_DATABASE_URL — базе с применёнными миграциями
+// (`DATABASE_URL=... go run . migrate up`); без неё тест пропускается
+func useTestDB(t *testing.T) {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	n, err := database.PendingMigrations(db)
+	if err != nil {
+		t.Fatalf("migration status: %v", err)
+	}
+	if n > 0 {
+		t.Fatalf("%d pending migration(s), run `migrate up` against TEST_DATABASE_URL", n)
+	}
+	prev := DB
+	DB = db
+	t.Cleanup(func() { DB = prev })
+}
+
+// editorRouter — маршруты от имени редактора, без проверки токена
+func editorRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set(claimsKey, &auth.Claims{Subject: "0", Email: "editor@example.com", Role: auth.RoleEditor})
+		c.Next()
+	})
+	r.POST("/api/grammars/full", CreateGrammarDocument)
+	r.PUT("/api/grammars/:id/full", UpdateGrammarDocument)
+	return r
+}
+
+func serveJSON(r http.Handler, method, path, ifMatch string, body any) *httptest.ResponseRecorder {
+	data, _ := json.Marshal(body)
+	req := httptest.NewRequest(method, path, bytes.NewReader(data))
+	req.Header.Set("Content-Type", "application/json")
+	if ifMatch != "" {
+		req.Header.Set("If-Match", ifMatch)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestDocumentTrashOrderChildrenFirst(t *testing.T) {
+	for i, name := range documentTrashOrder {
+		if parent := entities[name].parent; slices.Contains(documentTrashOrder[:i], parent) {
+			t.Errorf("%s goes after its parent %s", name, parent)
+		}
+	}
+}
+
+// Правило с примерами и исключениями, убранное из документа, уходит в корзину вместе с ними,
+// и сохранение не спотыкается о версии уже убранных детей
+func TestUpdateGrammarDocumentDropsRuleWithChildren(t *testing.T) {
+	useTestDB(t)
+	r := editorRouter()
+
+	// порядок обхода оставшихся id раньше зависел от обхода map, поэтому повторяем
+	for i := 0; i < 20; i++ {
+		w := serveJSON(r, http.MethodPost, "/api/grammars/full", "", map[string]any{
+			"language": "de",
+			"title_ru": fmt.Sprintf("Тест документа %d", i),
+			"rules": []map[string]any{
+				{"rule_name_ru": "Остаётся", "examples": []map[string]any{{"example_ru": "пример"}}},
+				{
+					"rule_name_ru": "Удаляется",
+					"examples":     []map[string]any{{"example_ru": "первый"}, {"example_ru": "второй"}},
+					"exceptions":   []map[string]any{{"description_ru": "исключение"}},
+				},
+			},
+		})
+		if w.Code != http.StatusCreated {
+			t.Fatalf("create: %d %s", w.Code, w.Body)
+		}
+		var doc map[string]any
+		if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+			t.Fatal(err)
+		}
+		doc["rules"] = doc["rules"].([]any)[:1]
+
+		path := fmt.Sprintf("/api/grammars/%v/full", doc["id"])
+		w = serveJSON(r, http.MethodPut, path, w.Header().Get("ETag"), doc)
+		if w.Code != http.StatusOK {
+			t.Fatalf("attempt %d: update: %d %s", i, w.Code, w.Body)
+		}
+		var saved struct {
+			Rules []struct {
+				Examples []any `json:"examples"`
+			} `json:"rules"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &saved); err != nil {
+			t.Fatal(err)
+		}
+		if len(saved.Rules) != 1 || len(saved.Rules[0].Examples) != 1 {
+			t.Fatalf("attempt %d: unexpected tree %s", i, w.Body)
+		}
+	}
+}
@@ -3,61 +3,275 @@ package handlers
 import (
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
 type Grammars struct {
-	ID            int    `gorm:"primaryKey;column:id"   json:"id"`
-	TitleRu       string `gorm:"column:title_ru"        json:"title_ru"`
-	TitleEn       string `gorm:"column:title_en"        json:"title_en"`
-	TitleDe       string `gorm:"column:title_de"        json:"title_de"`
-	DescriptionRu string `gorm:"column:description_ru"  json:"description_ru"`
-	DescriptionEn string `gorm:"column:description_en"  json:"description_en"`
-	DescriptionDe string `gorm:"column:description_de"  json:"description_de"`
-	Language      string `gorm:"column:language"        json:"language"`
+	ID           int                  `gorm:"primaryKey;column:id"   json:"id"`
+	Language     string               `gorm:"column:language"        json:"language"`
+	Status       string               `gorm:"column:status;<-:create" json:"status"`
+	PublishAt    *time.Time           `gorm:"column:publish_at;<-:create" json:"publish_at"`
+	DeletedAt    gorm.DeletedAt       `gorm:"column:deleted_at"      json:"-"`
+	Version      int                  `gorm:"column:version;default:1" json:"-"`
+	UpdatedAt    time.Time            `gorm:"column:updated_at"        json:"updated_at"`
+	Translations []GrammarTranslation `gorm:"foreignKey:GrammarID"   json:"-"`
+}
+
+type GrammarTranslation struct {
+	GrammarID   int    `gorm:"primaryKey;column:grammar_id"`
+	Lang        string `gorm:"primaryKey;column:lang"`
+	Title       string `gorm:"column:title"`
+	Description string `gorm:"column:description"`
+}
+
+func (t *GrammarTranslation) language() string        { return t.Lang }
+func (t *GrammarTranslation) setLanguage(lang string) { t.Lang = lang }
+func (t *GrammarTranslation) setOwner(id int)         { t.GrammarID = id }
+func (t *GrammarTranslation) jsonFields() map[string]any {
+	return map[string]any{
+		"title":       &t.Title,
+		"description": &t.Description,
+	}
+}
+
+type grammarsJSON Grammars
+
+func (g Grammars) MarshalJSON() ([]byte, error) {
+	return marshalTranslated(grammarsJSON(g), g.Translations, nil)
+}
+
+func (g *Grammars) UnmarshalJSON(data []byte) error {
+	return unmarshalTranslated(data, (*grammarsJSON)(g), &g.Translations, nil)
 }
 
 type GrammarRules struct {
-	ID                int    `gorm:"primaryKey;column:id"              json:"id"`
-	GrammarID         int    `gorm:"column:grammar_id;index"           json:"grammar_id"`
-	RuleNameRu        string `gorm:"column:rule_name_ru"               json:"rule_name_ru"`
-	RuleNameEn        string `gorm:"column:rule_name_en"               json:"rule_name_en"`
-	RuleNameDe        string `gorm:"column:rule_name_de"               json:"rule_name_de"`
-	RuleDescriptionRu string `gorm:"column:rule_description_ru"        json:"rule_description_ru"`
-	RuleDescriptionEn string `gorm:"column:rule_description_en"        json:"rule_description_en"`
-	RuleDescriptionDe string `gorm:"column:rule_description_de"        json:"rule_description_de"`
+	ID           int                      `gorm:"primaryKey;column:id"              json:"id"`
+	GrammarID    int                      `gorm:"column:grammar_id;index"           json:"grammar_id"`
+	DeletedAt    gorm.DeletedAt           `gorm:"column:deleted_at"                 json:"-"`
+	Version      int                      `gorm:"column:version;default:1"          json:"-"`
+	UpdatedAt    time.Time                `gorm:"column:updated_at"                 json:"updated_at"`
+	Translations []GrammarRuleTranslation `gorm:"foreignKey:RuleID"                 json:"-"`
+}
+
+type GrammarRuleTranslation struct {
+	RuleID      int    `gorm:"primaryKey;column:rule_id"`
+	Lang        string `gorm:"primaryKey;column:lang"`
+	Name        string `gorm:"column:name"`
+	Description string `gorm:"column:description"`
+}
+
+func (t *GrammarRuleTranslation) language() string        { return t.Lang }
+func (t *GrammarRuleTranslation) setLanguage(lang string) { t.Lang = lang }
+func (t *GrammarRuleTranslation) setOwner(id int)         { t.RuleID = id }
+func (t *GrammarRuleTranslation) jsonFields() map[string]any {
+	return map[string]any{
+		"rule_name":        &t.Name,
+		"rule_description": &t.Description,
+	}
+}
+
+type grammarRulesJSON GrammarRules
+
+func (r GrammarRules) MarshalJSON() ([]byte, error) {
+	return marshalTranslated(grammarRulesJSON(r), r.Translations, nil)
+}
+
+func (r *GrammarRules) UnmarshalJSON(data []byte) error {
+	return unmarshalTranslated(data, (*grammarRulesJSON)(r), &r.Translations, nil)
 }
 
 type GrammarExamples struct {
-	ID        int    `gorm:"primaryKey;column:id"    json:"id"`
-	RuleID    int    `gorm:"column:rule_id;index"    json:"rule_id"`
-	ExampleRu string `gorm:"column:example_ru"       json:"example_ru"`
-	ExampleEn string `gorm:"column:example_en"       json:"example_en"`
-	ExampleDe string `gorm:"column:example_de"       json:"example_de"`
+	ID           int                         `gorm:"primaryKey;column:id"    json:"id"`
+	RuleID       int                         `gorm:"column:rule_id;index"    json:"rule_id"`
+	DeletedAt    gorm.DeletedAt              `gorm:"column:deleted_at"       json:"-"`
+	Version      int                         `gorm:"column:version;default:1" json:"-"`
+	UpdatedAt    time.Time                   `gorm:"column:updated_at"        json:"updated_at"`
+	Translations []GrammarExampleTranslation `gorm:"foreignKey:ExampleID"    json:"-"`
+}
+
+type GrammarExampleTranslation struct {
+	ExampleID int    `gorm:"primaryKey;column:example_id"`
+	Lang      string `gorm:"primaryKey;column:lang"`
+	Example   string `gorm:"column:example"`
+}
+
+func (t *GrammarExampleTranslation) language() string        { return t.Lang }
+func (t *GrammarExampleTranslation) setLanguage(lang string) { t.Lang = lang }
+func (t *GrammarExampleTranslation) setOwner(id int)         { t.ExampleID = id }
+func (t *GrammarExampleTranslation) jsonFields() map[string]any {
+	return map[string]any{"example": &t.Example}
+}
+
+type grammarExamplesJSON GrammarExamples
+
+func (e GrammarExamples) MarshalJSON() ([]byte, error) {
+	return marshalTranslated(grammarExamplesJSON(e), e.Translations, nil)
+}
+
+func (e *GrammarExamples) UnmarshalJSON(data []byte) error {
+	return unmarshalTranslated(data, (*grammarExamplesJSON)(e), &e.Translations, nil)
 }
 
 type GrammarExceptions struct {
-	ID            int    `gorm:"primaryKey;column:id"       json:"id"`
-	RuleID        int    `gorm:"column:rule_id;index"       json:"rule_id"`
-	DescriptionRu string `gorm:"column:description_ru"      json:"description_ru"`
-	DescriptionEn string `gorm:"column:description_en"      json:"description_en"`
-	DescriptionDe string `gorm:"column:description_de"      json:"description_de"`
-	ExplanationRu string `gorm:"column:explanation_ru"      json:"explanation_ru"`
-	ExplanationEn string `gorm:"column:explanation_en"      json:"explanation_en"`
-	ExplanationDe string `gorm:"column:explanation_de"      json:"explanation_de"`
+	ID           int                           `gorm:"primaryKey;column:id"       json:"id"`
+	RuleID       int                           `gorm:"column:rule_id;index"       json:"rule_id"`
+	DeletedAt    gorm.DeletedAt                `gorm:"column:deleted_at"          json:"-"`
+	Version      int                           `gorm:"column:version;default:1"   json:"-"`
+	UpdatedAt    time.Time                     `gorm:"column:updated_at"          json:"updated_at"`
+	Translations []GrammarExceptionTranslation `gorm:"foreignKey:ExceptionID"     json:"-"`
+}
+
+type GrammarExceptionTranslation struct {
+	ExceptionID int    `gorm:"primaryKey;column:exception_id"`
+	Lang        string `gorm:"primaryKey;column:lang"`
+	Description string `gorm:"column:description"`
+	Explanation string `gorm:"column:explanation"`
+}
+
+func (t *GrammarExceptionTranslation) language() string        { return t.Lang }
+func (t *GrammarExceptionTranslation) setLanguage(lang string) { t.Lang = lang }
+func (t *GrammarExceptionTranslation) setOwner(id int)         { t.ExceptionID = id }
+func (t *GrammarExceptionTranslation) jsonFields() map[string]any {
+	return map[string]any{
+		"description": &t.Description,
+		"explanation": &t.Explanation,
+	}
+}
+
+type grammarExceptionsJSON GrammarExceptions
+
+func (e GrammarExceptions) MarshalJSON() ([]byte, error) {
+	return marshalTranslated(grammarExceptionsJSON(e), e.Translations, nil)
+}
+
+func (e *GrammarExceptions) UnmarshalJSON(data []byte) error {
+	return unmarshalTranslated(data, (*grammarExceptionsJSON)(e), &e.Translations, nil)
+}
+
+var grammarList = &listSpec{
+	table: "grammars",
+	sorts: map[string]sortField{
+		"language": {expr: "grammars.language", kind: sortText},
+		"status":   {expr: "grammars.status", kind: sortText},
+	},
+	translated: []translatedSort{
+		{prefix: "title", table: "grammar_translations", fk: "grammar_id", column: "title"},
+	},
+	filters: map[string]listFilter{
+		"language": textFilter("grammars.language"),
+		"status":   textFilter("grammars.status"),
+	},
+}
+
+var grammarRuleList = &listSpec{
+	table: "grammar_rules",
+	sorts: map[string]sortField{
+		"grammar_id": {expr: "grammar_rules.grammar_id", kind: sortInt},
+	},
+	translated: []translatedSort{
+		{prefix: "rule_name", table: "grammar_rule_translations", fk: "rule_id", column: "name"},
+	},
+	filters: map[string]listFilter{
+		"grammar_id": intFilter("grammar_rules.grammar_id"),
+		"language":   languageFilter("grammar_rules", "grammar_rule_translations", "rule_id", "name"),
+	},
+}
+
+var grammarExampleList = &listSpec{
+	table: "grammar_examples",
+	sorts: map[string]sortField{
+		"rule_id": {expr: "grammar_examples.rule_id", kind: sortInt},
+	},
+	translated: []translatedSort{
+		{prefix: "example", table: "grammar_example_translations", fk: "example_id", column: "example"},
+	},
+	filters: map[string]listFilter{
+		"rule_id":  intFilter("grammar_examples.rule_id"),
+		"language": languageFilter("grammar_examples", "grammar_example_translations", "example_id", "example"),
+	},
+}
+
+var grammarExceptionList = &listSpec{
+	table: "grammar_exceptions",
+	sorts: map[string]sortField{
+		"rule_id": {expr: "grammar_exceptions.rule_id", kind: sortInt},
+	},
+	translated: []translatedSort{
+		{prefix: "description", table: "grammar_exception_translations", fk: "exception_id", column: "description"},
+	},
+	filters: map[string]listFilter{
+		"rule_id":  intFilter("grammar_exceptions.rule_id"),
+		"language": languageFilter("grammar_exceptions", "grammar_exception_translations", "exception_id", "description"),
+	},
+}
+
+var grammarFields = &fieldSpec{
+	table:      "grammars",
+	columns:    map[string]string{"language": "language", "status": "status", "publish_at": "publish_at", "updated_at": "updated_at"},
+	trFK:       "grammar_id",
+	translated: map[string]string{"title": "title", "description": "description"},
+}
+
+var grammarRuleFields = &fieldSpec{
+	table:      "grammar_rules",
+	columns:    map[string]string{"grammar_id": "grammar_id", "updated_at": "updated_at"},
+	trFK:       "rule_id",
+	translated: map[string]string{"rule_name": "name", "rule_description": "description"},
+}
+
+var grammarExampleFields = &fieldSpec{
+	table:      "grammar_examples",
+	columns:    map[string]string{"rule_id": "rule_id", "updated_at": "updated_at"},
+	trFK:       "example_id",
+	translated: map[string]string{"example": "example"},
+}
+
+var grammarExceptionFields = &fieldSpec{
+	table:      "grammar_exceptions",
+	columns:    map[string]string{"rule_id": "rule_id", "updated_at": "updated_at"},
+	trFK:       "exception_id",
+	translated: map[string]string{"description": "description", "explanation": "explanation"},
 }
 
 func GetGrammars(c *gin.Context) {
+	p, ok := parseFields(c, grammarFields, false)
+	if !ok {
+		return
+	}
 	var g []Grammars
-	if err := DB.Find(&g).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if !findPage(c, grammarList, visibleTo(c, "grammars", DB), p.apply, &g) {
 		return
 	}
 
-	c.JSON(http.StatusOK, g)
+	p.respond(c, http.StatusOK, g)
+
+}
 
+func GetGrammar(c *gin.Context) {
+	id, ok := getID(c)
+	if !ok {
+		return
+	}
+	p, ok := parseFields(c, grammarFields, true)
+	if !ok {
+		return
+	}
+	var g Grammars
+	if err := p.apply(visibleTo(c, "grammars", DB)).First(&g, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "grammar not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	if setETag(c, versionETag(g.Version)) {
+		return
+	}
+	p.respond(c, http.StatusOK, g)
 }
 
 func CreateGrammars(c *gin.Context) {
@@ -66,12 +280,16 @@ func CreateGrammars(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
 		return
 	}
+	if !validateUnderWorkflow(c, g, g.Status, g.PublishAt, statusDraft, nil) {
+		return
+	}
 
-	if err := DB.Create(&g).Error; err != nil {
+	if err := createRecorded(actorOf(c), "grammars", &g, &g.ID); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	c.Header("ETag", versionETag(g.Version))
 	c.JSON(http.StatusOK, g)
 }
 
@@ -83,7 +301,7 @@ func UpdateGrammars(c *gin.Context) {
 	}
 
 	var g Grammars
-	if err = DB.First(&g, id).Error; err != nil {
+	if err = DB.Preload("Translations").First(&g, id).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, gin.H{"error": "grammar not found"})
 		} else {
@@ -91,46 +309,75 @@ func UpdateGrammars(c *gin.Context) {
 		}
 		return
 	}
-
-	var input Grammars
-	if err = c.BindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	version := g.Version
+	if !checkIfMatch(c, versionETag(version), currentItem("grammars", id)) {
 		return
 	}
 
-	if err = DB.Session(&gorm.Session{FullSaveAssociations: true}).
-		Model(&Grammars{ID: id}).Updates(input).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	// входные поля накладываются на загруженную грамматику, отсутствующие не меняются;
+	// статус меняется только переходами
+	status, publishAt := g.Status, g.PublishAt
+	g.Status, g.PublishAt = "", nil
+	if err = c.BindJSON(&g); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-
-	c.JSON(http.StatusOK, input)
-}
-
-func DeleteGrammars(c *gin.Context) {
-	id, err := strconv.Atoi(c.Param("id"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+	if !validateUnderWorkflow(c, g, g.Status, g.PublishAt, status, publishAt) {
 		return
 	}
+	g.Status, g.PublishAt = status, publishAt
+	g.ID = id
+	g.Version = version + 1
 
-	if err = DB.Delete(&Grammars{}, id).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err = saveTranslated(actorOf(c), &g, "grammars", id, version, g.Translations); err != nil {
+		saveFailed(c, err, currentItem("grammars", id))
 		return
 	}
 
-	c.Status(http.StatusNoContent)
+	c.Header("ETag", versionETag(g.Version))
+	c.JSON(http.StatusOK, g)
+}
+
+func DeleteGrammars(c *gin.Context) {
+	moveToTrash(c, "grammars")
 }
 
 // ************** GrammarRules **************
 
 func GetGrammarRules(c *gin.Context) {
+	p, ok := parseFields(c, grammarRuleFields, false)
+	if !ok {
+		return
+	}
 	var items []GrammarRules
-	if err := DB.Find(&items).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if !findPage(c, grammarRuleList, visibleTo(c, "grammar_rules", DB), p.apply, &items) {
 		return
 	}
-	c.JSON(http.StatusOK, items)
+	p.respond(c, http.StatusOK, items)
+}
+
+func GetGrammarRule(c *gin.Context) {
+	id, ok := getID(c)
+	if !ok {
+		return
+	}
+	p, ok := parseFields(c, grammarRuleFields, true)
+	if !ok {
+		return
+	}
+	var item GrammarRules
+	if err := p.apply(visibleTo(c, "grammar_rules", DB)).First(&item, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	if setETag(c, versionETag(item.Version)) {
+		return
+	}
+	p.respond(c, http.StatusOK, item)
 }
 
 func CreateGrammarRules(c *gin.Context) {
@@ -138,10 +385,14 @@ func CreateGrammarRules(c *gin.Context) {
 	if !bindJSON(c, &item) {
 		return
 	}
-	if err := DB.Create(&item).Error; err != nil {
+	if !validate(c, item) {
+		return
+	}
+	if err := createRecorded(actorOf(c), "grammar_rules", &item, &item.ID); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	c.Header("ETag", versionETag(item.Version))
 	c.JSON(http.StatusCreated, item)
 }
 
@@ -152,7 +403,7 @@ func UpdateGrammarRules(c *gin.Context) {
 	}
 
 	var item GrammarRules
-	if err := DB.First(&item, id).Error; err != nil {
+	if err := DB.Preload("Translations").First(&item, id).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
 		} else {
@@ -160,43 +411,68 @@ func UpdateGrammarRules(c *gin.Context) {
 		}
 		return
 	}
+	version := item.Version
+	if !checkIfMatch(c, versionETag(version), currentItem("grammar_rules", id)) {
+		return
+	}
 
-	var input GrammarRules
-	if !bindJSON(c, &input) {
+	if !bindJSON(c, &item) {
+		return
+	}
+	if !validate(c, item) {
 		return
 	}
-	input.ID = id
+	item.ID = id
+	item.Version = version + 1
 
-	if err := DB.Session(&gorm.Session{FullSaveAssociations: true}).
-		Model(&item).
-		Updates(input).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := saveTranslated(actorOf(c), &item, "grammar_rules", id, version, item.Translations); err != nil {
+		saveFailed(c, err, currentItem("grammar_rules", id))
 		return
 	}
-	c.JSON(http.StatusOK, input)
+	c.Header("ETag", versionETag(item.Version))
+	c.JSON(http.StatusOK, item)
 }
 
 func DeleteGrammarRules(c *gin.Context) {
-	id, ok := getID(c)
+	moveToTrash(c, "grammar_rules")
+}
+
+// ************** GrammarExamples **************
+
+func GetGrammarExamples(c *gin.Context) {
+	p, ok := parseFields(c, grammarExampleFields, false)
 	if !ok {
 		return
 	}
-	if err := DB.Delete(&GrammarRules{}, id).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	var items []GrammarExamples
+	if !findPage(c, grammarExampleList, visibleTo(c, "grammar_examples", DB), p.apply, &items) {
 		return
 	}
-	c.Status(http.StatusNoContent)
+	p.respond(c, http.StatusOK, items)
 }
 
-// ************** GrammarExamples **************
-
-func GetGrammarExamples(c *gin.Context) {
-	var items []GrammarExamples
-	if err := DB.Find(&items).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+func GetGrammarExample(c *gin.Context) {
+	id, ok := getID(c)
+	if !ok {
+		return
+	}
+	p, ok := parseFields(c, grammarExampleFields, true)
+	if !ok {
+		return
+	}
+	var item GrammarExamples
+	if err := p.apply(visibleTo(c, "grammar_examples", DB)).First(&item, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	if setETag(c, versionETag(item.Version)) {
 		return
 	}
-	c.JSON(http.StatusOK, items)
+	p.respond(c, http.StatusOK, item)
 }
 
 func CreateGrammarExamples(c *gin.Context) {
@@ -204,10 +480,14 @@ func CreateGrammarExamples(c *gin.Context) {
 	if !bindJSON(c, &item) {
 		return
 	}
-	if err := DB.Create(&item).Error; err != nil {
+	if !validate(c, item) {
+		return
+	}
+	if err := createRecorded(actorOf(c), "grammar_examples", &item, &item.ID); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	c.Header("ETag", versionETag(item.Version))
 	c.JSON(http.StatusCreated, item)
 }
 
@@ -218,7 +498,7 @@ func UpdateGrammarExamples(c *gin.Context) {
 	}
 
 	var item GrammarExamples
-	if err := DB.First(&item, id).Error; err != nil {
+	if err := DB.Preload("Translations").First(&item, id).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
 		} else {
@@ -226,41 +506,68 @@ func UpdateGrammarExamples(c *gin.Context) {
 		}
 		return
 	}
+	version := item.Version
+	if !checkIfMatch(c, versionETag(version), currentItem("grammar_examples", id)) {
+		return
+	}
 
-	var input GrammarExamples
-	if !bindJSON(c, &input) {
+	if !bindJSON(c, &item) {
+		return
+	}
+	if !validate(c, item) {
 		return
 	}
-	input.ID = id
+	item.ID = id
+	item.Version = version + 1
 
-	if err := DB.Model(&item).Updates(input).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := saveTranslated(actorOf(c), &item, "grammar_examples", id, version, item.Translations); err != nil {
+		saveFailed(c, err, currentItem("grammar_examples", id))
 		return
 	}
-	c.JSON(http.StatusOK, input)
+	c.Header("ETag", versionETag(item.Version))
+	c.JSON(http.StatusOK, item)
 }
 
 func DeleteGrammarExamples(c *gin.Context) {
-	id, ok := getID(c)
+	moveToTrash(c, "grammar_examples")
+}
+
+// ************** GrammarExceptions **************
+
+func GetGrammarExceptions(c *gin.Context) {
+	p, ok := parseFields(c, grammarExceptionFields, false)
 	if !ok {
 		return
 	}
-	if err := DB.Delete(&GrammarExamples{}, id).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	var items []GrammarExceptions
+	if !findPage(c, grammarExceptionList, visibleTo(c, "grammar_exceptions", DB), p.apply, &items) {
 		return
 	}
-	c.Status(http.StatusNoContent)
+	p.respond(c, http.StatusOK, items)
 }
 
-// ************** GrammarExceptions **************
-
-func GetGrammarExceptions(c *gin.Context) {
-	var items []GrammarExceptions
-	if err := DB.Find(&items).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+func GetGrammarException(c *gin.Context) {
+	id, ok := getID(c)
+	if !ok {
+		return
+	}
+	p, ok := parseFields(c, grammarExceptionFields, true)
+	if !ok {
 		return
 	}
-	c.JSON(http.StatusOK, items)
+	var item GrammarExceptions
+	if err := p.apply(visibleTo(c, "grammar_exceptions", DB)).First(&item, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	if setETag(c, versionETag(item.Version)) {
+		return
+	}
+	p.respond(c, http.StatusOK, item)
 }
 
 func CreateGrammarExceptions(c *gin.Context) {
@@ -268,10 +575,14 @@ func CreateGrammarExceptions(c *gin.Context) {
 	if !bindJSON(c, &item) {
 		return
 	}
-	if err := DB.Create(&item).Error; err != nil {
+	if !validate(c, item) {
+		return
+	}
+	if err := createRecorded(actorOf(c), "grammar_exceptions", &item, &item.ID); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	c.Header("ETag", versionETag(item.Version))
 	c.JSON(http.StatusCreated, item)
 }
 
@@ -282,7 +593,7 @@ func UpdateGrammarExceptions(c *gin.Context) {
 	}
 
 	var item GrammarExceptions
-	if err := DB.First(&item, id).Error; err != nil {
+	if err := DB.Preload("Translations").First(&item, id).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
 		} else {
@@ -290,28 +601,28 @@ func UpdateGrammarExceptions(c *gin.Context) {
 		}
 		return
 	}
-
-	var input GrammarExceptions
-	if !bindJSON(c, &input) {
+	version := item.Version
+	if !checkIfMatch(c, versionETag(version), currentItem("grammar_exceptions", id)) {
 		return
 	}
-	input.ID = id
 
-	if err := DB.Model(&item).Updates(input).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if !bindJSON(c, &item) {
 		return
 	}
-	c.JSON(http.StatusOK, input)
-}
-
-func DeleteGrammarExceptions(c *gin.Context) {
-	id, ok := getID(c)
-	if !ok {
+	if !validate(c, item) {
 		return
 	}
-	if err := DB.Delete(&GrammarExceptions{}, id).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	item.ID = id
+	item.Version = version + 1
+
+	if err := saveTranslated(actorOf(c), &item, "grammar_exceptions", id, version, item.Translations); err != nil {
+		saveFailed(c, err, currentItem("grammar_exceptions", id))
 		return
 	}
-	c.Status(http.StatusNoContent)
+	c.Header("ETag", versionETag(item.Version))
+	c.JSON(http.StatusOK, item)
+}
+
+func DeleteGrammarExceptions(c *gin.Context) {
+	moveToTrash(c, "grammar_exceptions")
 }
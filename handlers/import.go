@@ -0,0 +1,445 @@
+package handlers
+
+import (
+	"database/sql/driver"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"bd_back_for_translate_app/languages"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	maxImportSize = 10 << 20
+	maxImportRows = 10000
+)
+
+// importSpec — что можно импортировать в сущность: столбцы и переводимые поля (<поле>_<язык>)
+type importSpec struct {
+	name       string
+	columns    []string
+	translated []string
+	// key — главное поле перевода: по нему ищутся пропущенные языки и дубликаты
+	key string
+	// keyColumn — колонка key в таблице переводов
+	trTable, trFK, keyColumn string
+}
+
+var wordImport = &importSpec{
+	name:       "words",
+	columns:    []string{"category_id"},
+	translated: []string{"word", "transcription", "type"},
+	key:        "word",
+	trTable:    "word_translations",
+	trFK:       "word_id",
+	keyColumn:  "word",
+}
+
+var textImport = &importSpec{
+	name:       "texts",
+	columns:    []string{"category_id"},
+	translated: []string{"title", "content", "transcription"},
+	key:        "title",
+	trTable:    "text_translations",
+	trFK:       "text_id",
+	keyColumn:  "title",
+}
+
+// field проверяет, что name — поле API этой сущности
+func (s *importSpec) field(name string) bool {
+	for _, col := range s.columns {
+		if name == col {
+			return true
+		}
+	}
+	for _, f := range s.translated {
+		if lang, ok := strings.CutPrefix(name, f+"_"); ok && languages.Supported(lang) {
+			return true
+		}
+	}
+	return false
+}
+
+// ImportRow — результат проверки одной строки файла; row — номер строки с учётом заголовка
+type ImportRow struct {
+	Row              int          `json:"row"`
+	OK               bool         `json:"ok"`
+	ID               int          `json:"id,omitempty"`
+	Errors           []FieldError `json:"errors,omitempty"`
+	MissingLanguages []string     `json:"missing_languages,omitempty"`
+	DuplicateOfRow   int          `json:"duplicate_of_row,omitempty"`
+	DuplicateOfID    int          `json:"duplicate_of_id,omitempty"`
+}
+
+// ImportReport — ответ импорта; created — сколько записей вставлено (0 в пробном режиме)
+type ImportReport struct {
+	DryRun  bool        `json:"dry_run"`
+	Total   int         `json:"total"`
+	Valid   int         `json:"valid"`
+	Invalid int         `json:"invalid"`
+	Created int         `json:"created"`
+	Rows    []ImportRow `json:"rows"`
+}
+
+// ImportWords — POST /api/import/words
+func ImportWords(c *gin.Context) {
+	runImport(c, wordImport, func() any { return new(Word) })
+}
+
+// ImportTexts — POST /api/import/texts
+func ImportTexts(c *gin.Context) {
+	runImport(c, textImport, func() any { return new(Text) })
+}
+
+// runImport разбирает CSV/TSV и проверяет каждую строку. По умолчанию ничего не пишет;
+// с commit=true вставляет все строки одной транзакцией, если ни в одной нет ошибок.
+// Озвучка ставится в очередь после коммита
+func runImport(c *gin.Context, spec *importSpec, newObj func() any) {
+	commit, _ := strconv.ParseBool(c.Query("commit"))
+
+	records, ok := readImportFile(c)
+	if !ok {
+		return
+	}
+	if len(records) < 2 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file must contain a header and at least one row"})
+		return
+	}
+	if len(records)-1 > maxImportRows {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("too many rows, max %d", maxImportRows)})
+		return
+	}
+	targets, ok := importColumns(c, spec, records[0])
+	if !ok {
+		return
+	}
+
+	rows := make([]map[string]string, len(records)-1)
+	for i, rec := range records[1:] {
+		fields := map[string]string{}
+		for j, target := range targets {
+			if target != "" && j < len(rec) {
+				fields[target] = strings.TrimSpace(rec[j])
+			}
+		}
+		rows[i] = fields
+	}
+	existing, err := existingKeys(spec, rows)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	report := ImportReport{DryRun: !commit, Total: len(records) - 1, Rows: make([]ImportRow, len(records)-1)}
+	objs := make([]any, len(records)-1)
+	seen := map[string]int{}
+	for i, fields := range rows {
+		r := &report.Rows[i]
+		r.Row = i + 2
+
+		obj, err := importObject(fields, newObj())
+		if err != nil {
+			r.Errors = append(r.Errors, FieldError{Code: codeInvalid, Message: err.Error()})
+			continue
+		}
+		objs[i] = obj
+
+		// те же правила, что и у POST; ошибки полей идут в отчёт строки
+		v := newValidator()
+		obj.(validatable).validate(v)
+		if v.err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": v.err.Error()})
+			return
+		}
+
+		for _, lang := range languages.Codes() {
+			value := fields[spec.key+"_"+lang]
+			if value == "" {
+				r.MissingLanguages = append(r.MissingLanguages, lang)
+				continue
+			}
+			// дубликат — то же значение на том же языке в той же категории
+			key := importKey(atoiOrZero(fields["category_id"]), lang, value)
+			if prev, ok := seen[key]; ok && r.DuplicateOfRow == 0 {
+				r.DuplicateOfRow = prev
+				v.add(spec.key+"_"+lang, codeDuplicate, "duplicate of row %d", prev)
+			} else if !ok {
+				seen[key] = r.Row
+			}
+			if id := existing[key]; id != 0 && r.DuplicateOfID == 0 {
+				r.DuplicateOfID = id
+				v.add(spec.key+"_"+lang, codeDuplicate, "duplicate of %s id %d", spec.name, id)
+			}
+		}
+		r.Errors = v.errs
+	}
+	for i := range report.Rows {
+		report.Rows[i].OK = len(report.Rows[i].Errors) == 0
+		if report.Rows[i].OK {
+			report.Valid++
+		} else {
+			report.Invalid++
+		}
+	}
+
+	if !commit {
+		c.JSON(http.StatusOK, report)
+		return
+	}
+	if report.Invalid > 0 {
+		c.JSON(http.StatusUnprocessableEntity, report)
+		return
+	}
+
+	actor := actorOf(c)
+	ids := make([]int, len(objs))
+	err = DB.Transaction(func(tx *gorm.DB) error {
+		for i, obj := range objs {
+			if err := tx.Create(obj).Error; err != nil {
+				return fmt.Errorf("row %d: %w", report.Rows[i].Row, err)
+			}
+			ids[i] = int(reflect.ValueOf(obj).Elem().FieldByName("ID").Int())
+			if err := recordRevision(tx, actor, spec.name, ids[i], actionCreate, nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	for i := range report.Rows {
+		report.Rows[i].ID = ids[i]
+	}
+	report.Created = len(ids)
+	enqueueAudio(spec.name, ids)
+	c.JSON(http.StatusCreated, report)
+}
+
+// readImportFile читает файл из поля file формы или из тела запроса.
+// Разделитель: format=csv|tsv, иначе по расширению .tsv или по табуляции в заголовке
+func readImportFile(c *gin.Context) ([][]string, bool) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize)
+
+	var (
+		src  io.Reader = c.Request.Body
+		name string
+	)
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		file, header, err := c.Request.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+			return nil, false
+		}
+		defer file.Close()
+		src, name = file, header.Filename
+	}
+	data, err := io.ReadAll(src)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	text := strings.TrimPrefix(string(data), "\uFEFF")
+
+	format := c.Query("format")
+	if format == "" {
+		firstLine, _, _ := strings.Cut(text, "\n")
+		if strings.EqualFold(filepath.Ext(name), ".tsv") || strings.Contains(firstLine, "\t") {
+			format = "tsv"
+		} else {
+			format = "csv"
+		}
+	}
+	r := csv.NewReader(strings.NewReader(text))
+	switch format {
+	case "csv":
+	case "tsv":
+		r.Comma = '\t'
+		r.LazyQuotes = true
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be csv or tsv"})
+		return nil, false
+	}
+	r.FieldsPerRecord = -1
+
+	records, err := r.ReadAll()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return records, true
+}
+
+// importColumns сопоставляет столбцы файла полям API. mapping — JSON-объект
+// {"заголовок": "поле"}; без него заголовок должен совпадать с именем поля.
+// Пустое поле в mapping пропускает столбец
+func importColumns(c *gin.Context, spec *importSpec, header []string) ([]string, bool) {
+	mapping := map[string]string{}
+	if v := c.DefaultPostForm("mapping", c.Query("mapping")); v != "" {
+		if err := json.Unmarshal([]byte(v), &mapping); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "mapping must be a JSON object"})
+			return nil, false
+		}
+	}
+
+	targets := make([]string, len(header))
+	used := map[string]string{}
+	for i, h := range header {
+		h = strings.TrimSpace(h)
+		target, mapped := mapping[h]
+		if !mapped {
+			target = h
+		}
+		if target == "" {
+			continue
+		}
+		if !spec.field(target) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("column %q: unknown field %q", h, target)})
+			return nil, false
+		}
+		if prev, ok := used[target]; ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("columns %q and %q both map to %q", prev, h, target)})
+			return nil, false
+		}
+		used[target] = h
+		targets[i] = target
+	}
+	for h := range mapping {
+		found := false
+		for _, col := range header {
+			if strings.TrimSpace(col) == h {
+				found = true
+				break
+			}
+		}
+		if !found {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("mapping: no column %q in header", h)})
+			return nil, false
+		}
+	}
+	return targets, true
+}
+
+// importObject собирает запись из полей строки так же, как из JSON запроса
+func importObject(fields map[string]string, obj any) (any, error) {
+	raw := make(map[string]any, len(fields))
+	for k, v := range fields {
+		raw[k] = v
+	}
+	if v, ok := fields["category_id"]; ok {
+		id := 0
+		if v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("category_id: invalid value %q", v)
+			}
+			id = n
+		}
+		raw["category_id"] = id
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, obj); err != nil {
+		return nil, err
+	}
+	// пустые ячейки не создают перевод
+	list := reflect.ValueOf(obj).Elem().FieldByName("Translations")
+	kept := reflect.MakeSlice(list.Type(), 0, list.Len())
+	for i := 0; i < list.Len(); i++ {
+		for _, ptr := range list.Index(i).Addr().Interface().(translation).jsonFields() {
+			if !reflect.ValueOf(ptr).Elem().IsZero() {
+				kept = reflect.Append(kept, list.Index(i))
+				break
+			}
+		}
+	}
+	list.Set(kept)
+	return obj, nil
+}
+
+// importKey — ключ поиска дубликатов: категория, язык и значение без учёта регистра
+func importKey(category int, lang, value string) string {
+	return fmt.Sprintf("%d\x00%s\x00%s", category, lang, strings.ToLower(value))
+}
+
+// existingKeys одним запросом находит живые записи, у которых ключевое поле совпадает
+// с каким-нибудь значением из файла; результат — importKey → наименьший id
+func existingKeys(spec *importSpec, rows []map[string]string) (map[string]int, error) {
+	var values textArray
+	categories := map[int]bool{}
+	for _, fields := range rows {
+		for _, lang := range languages.Codes() {
+			if value := fields[spec.key+"_"+lang]; value != "" {
+				values = append(values, value)
+				categories[atoiOrZero(fields["category_id"])] = true
+			}
+		}
+	}
+	existing := map[string]int{}
+	if len(values) == 0 {
+		return existing, nil
+	}
+
+	var found []struct {
+		ID         int
+		CategoryID int
+		Lang       string
+		Value      string
+	}
+	err := DB.Table(spec.trTable+" t").
+		Select("e.id, COALESCE(e.category_id, 0) AS category_id, t.lang, t."+spec.keyColumn+" AS value").
+		Joins(fmt.Sprintf("JOIN %s e ON e.id = t.%s AND e.deleted_at IS NULL", spec.name, spec.trFK)).
+		Where("lower(t."+spec.keyColumn+") IN (SELECT lower(v) FROM unnest(CAST(? AS text[])) v)", values).
+		Where("COALESCE(e.category_id, 0) IN ?", slices.Collect(maps.Keys(categories))).
+		Order("e.id").
+		Scan(&found).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range found {
+		key := importKey(f.CategoryID, f.Lang, f.Value)
+		if _, ok := existing[key]; !ok {
+			existing[key] = f.ID
+		}
+	}
+	return existing, nil
+}
+
+// textArray — массив строк как один параметр text[] (gorm раскрыл бы обычный срез в список)
+type textArray []string
+
+var arrayEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+func (a textArray) Value() (driver.Value, error) {
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, s := range a {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteByte('"')
+		b.WriteString(arrayEscaper.Replace(s))
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+	return b.String(), nil
+}
+
+func atoiOrZero(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
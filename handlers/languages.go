@@ -0,0 +1,13 @@
+package handlers
+
+import (
+	"net/http"
+
+	"bd_back_for_translate_app/languages"
+
+	"github.com/gin-gonic/gin"
+)
+
+func GetLanguages(c *gin.Context) {
+	c.JSON(http.StatusOK, languages.List())
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultLookupLimit = 10
+	maxLookupLimit     = 50
+)
+
+// LookupResult — кандидат нечёткого поиска: слово и перевод, который совпал лучше всего
+type LookupResult struct {
+	Word        Word    `json:"word"`
+	MatchedLang string  `json:"matched_lang"`
+	Matched     string  `json:"matched"`
+	Score       float64 `json:"score"`
+}
+
+// LookupWords — GET /api/words/lookup?q=&lang=&limit=: опечатки и варианты
+// написания (strasse/Straße, еж/ёж) через pg_trgm по fold_text(word)
+func LookupWords(c *gin.Context) {
+	q := strings.Join(strings.Fields(c.Query("q")), " ")
+	if q == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "q is required"})
+		return
+	}
+	lang := c.Query("lang")
+	if !validLanguage(c, lang) {
+		return
+	}
+
+	limit := defaultLookupLimit
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxLookupLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("limit must be between 1 and %d", maxLookupLimit)})
+			return
+		}
+		limit = n
+	}
+
+	type match struct {
+		WordID int
+		Lang   string
+		Word   string
+		Score  float64
+	}
+	var matches []match
+	// DISTINCT ON оставляет по одному лучшему переводу на слово;
+	// точное совпадение после нормализации всегда идёт первым
+	if err := DB.Raw(`
+		SELECT word_id, lang, word, score FROM (
+			SELECT DISTINCT ON (t.word_id) t.word_id, t.lang, t.word,
+			       CASE WHEN fold_text(t.word) = fold_text(@q) THEN 1
+			            ELSE similarity(fold_text(t.word), fold_text(@q)) END AS score
+			FROM word_translations t
+			JOIN words w ON w.id = t.word_id AND w.deleted_at IS NULL
+			WHERE fold_text(t.word) % fold_text(@q) AND (@lang = '' OR t.lang = @lang)
+			  AND (@editor OR `+published("w")+`)
+			ORDER BY t.word_id, score DESC
+		) m
+		ORDER BY score DESC, word_id
+		LIMIT @limit`, map[string]any{"q": q, "lang": lang, "limit": limit, "editor": isEditor(c)}).
+		Scan(&matches).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if len(matches) == 0 {
+		c.JSON(http.StatusOK, []LookupResult{})
+		return
+	}
+	ids := make([]int, len(matches))
+	for i, m := range matches {
+		ids[i] = m.WordID
+	}
+	var words []Word
+	if err := wordProjectionNoAudio().apply(DB).Find(&words, ids).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	byID := make(map[int]Word, len(words))
+	for _, w := range words {
+		byID[w.ID] = w
+	}
+
+	results := make([]LookupResult, 0, len(matches))
+	for _, m := range matches {
+		w, ok := byID[m.WordID]
+		if !ok {
+			continue
+		}
+		results = append(results, LookupResult{Word: w, MatchedLang: m.Lang, Matched: m.Word, Score: m.Score})
+	}
+	c.JSON(http.StatusOK, results)
+}
+
+// wordProjectionNoAudio — слово целиком, но без ссылок на клипы
+func wordProjectionNoAudio() *projection {
+	p, _ := newProjection("", "false", wordFields, false)
+	return p
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Orphan — живая запись со ссылкой на строку, которой нет (missing) или которая в корзине (deleted)
+type Orphan struct {
+	Type    string `json:"type"`
+	ID      int    `json:"id"`
+	Field   string `json:"field"`
+	RefType string `json:"ref_type"`
+	RefID   int    `json:"ref_id"`
+	Reason  string `json:"reason"`
+}
+
+// ForeignKey — состояние внешнего ключа; validated=false означает, что старые строки ещё не проверены
+type ForeignKey struct {
+	Name      string `json:"name"`
+	Table     string `json:"table"`
+	Validated bool   `json:"validated"`
+}
+
+// GetOrphans — GET /api/admin/orphans?limit=: висячие ссылки и состояние внешних ключей.
+// Ссылки перечислены в entities (parent/fk)
+func GetOrphans(c *gin.Context) {
+	limit := maxPageSize
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxPageSize {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("limit must be between 1 and %d", maxPageSize)})
+			return
+		}
+		limit = n
+	}
+
+	var (
+		parts  []string
+		tables []string
+	)
+	for _, name := range trashOrder {
+		t := entities[name]
+		if t.parent == "" {
+			continue
+		}
+		parts = append(parts, fmt.Sprintf(`
+			SELECT '%s' AS type, t.id, '%s' AS field, '%s' AS ref_type, t.%s AS ref_id,
+			       CASE WHEN p.id IS NULL THEN 'missing' ELSE 'deleted' END AS reason
+			FROM %s t LEFT JOIN %s p ON p.id = t.%s
+			WHERE t.deleted_at IS NULL AND t.%s IS NOT NULL AND (p.id IS NULL OR p.deleted_at IS NOT NULL)`,
+			name, t.fk, t.parent, t.fk, t.table, entities[t.parent].table, t.fk, t.fk))
+		tables = append(tables, t.table)
+	}
+
+	orphans := []Orphan{}
+	sql := strings.Join(parts, "\nUNION ALL\n") + "\nORDER BY type, id LIMIT ?"
+	if err := DB.Raw(sql, limit).Scan(&orphans).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	keys := []ForeignKey{}
+	if err := DB.Raw(`
+		SELECT conname AS name, conrelid::regclass::text AS "table", convalidated AS validated
+		FROM pg_constraint
+		WHERE contype = 'f' AND conrelid::regclass::text IN ?
+		ORDER BY conrelid::regclass::text, conname`, tables).Scan(&keys).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"orphans": orphans, "foreign_keys": keys})
+}
@@ -0,0 +1,375 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	progressSeen     = "seen"
+	progressLearning = "learning"
+	progressMastered = "mastered"
+	// masteredStability — слово выучено, когда до забывания не меньше трёх недель
+	masteredStability = 21.0
+	// maxSessionSeconds — сколько времени можно записать за один раз
+	maxSessionSeconds = 24 * 60 * 60
+	defaultStatsDays  = 30
+	maxStatsDays      = 366
+)
+
+var (
+	progressStatuses = []string{progressSeen, progressLearning, progressMastered}
+	// progressEntities — что учит пользователь; имена как в entities
+	progressEntities = []string{"words", "texts", "grammar_rules"}
+	// progressLangFields — таблица и поле перевода, по которому запись считается доступной на языке
+	progressLangFields = map[string][2]string{
+		"words":         {"word_translations", "word"},
+		"texts":         {"text_translations", "title"},
+		"grammar_rules": {"grammar_rule_translations", "name"},
+	}
+)
+
+// Progress — прогресс пользователя по одной записи; time_spent — в секундах
+type Progress struct {
+	UserID      int        `gorm:"primaryKey;column:user_id" json:"-"`
+	Entity      string     `gorm:"primaryKey;column:entity"  json:"entity"`
+	ItemID      int        `gorm:"primaryKey;column:item_id" json:"item_id"`
+	Status      string     `gorm:"column:status"             json:"status"`
+	TimeSpent   int        `gorm:"column:time_spent"         json:"time_spent"`
+	FirstSeenAt time.Time  `gorm:"column:first_seen_at"      json:"first_seen_at"`
+	UpdatedAt   time.Time  `gorm:"column:updated_at"         json:"updated_at"`
+	MasteredAt  *time.Time `gorm:"column:mastered_at"        json:"mastered_at"`
+}
+
+func (Progress) TableName() string { return "progress" }
+
+// wordStatus — статус слова по состоянию повторения
+func wordStatus(s ReviewState) string {
+	if s.State == "review" && s.Stability >= masteredStability {
+		return progressMastered
+	}
+	return progressLearning
+}
+
+// saveProgress добавляет seconds ко времени записи и, если status не пуст, ставит его.
+// mastered_at — момент, когда запись стала выученной; сбрасывается, если статус понизился
+func saveProgress(tx *gorm.DB, userID int, entity string, itemID int, status string, seconds int, at time.Time) (Progress, error) {
+	var p Progress
+	err := tx.Raw(`INSERT INTO progress (user_id, entity, item_id, status, time_spent, first_seen_at, updated_at, mastered_at)
+VALUES (@user, @entity, @item, coalesce(nullif(@status, ''), 'seen'), CAST(@seconds AS int), CAST(@at AS timestamptz), now(),
+        CASE WHEN @status = 'mastered' THEN CAST(@at AS timestamptz) END)
+ON CONFLICT (user_id, entity, item_id) DO UPDATE SET
+    status = CASE WHEN @status = '' THEN progress.status ELSE @status END,
+    time_spent = progress.time_spent + CAST(@seconds AS int),
+    updated_at = now(),
+    mastered_at = CASE
+        WHEN @status = '' OR (@status = 'mastered' AND progress.status = 'mastered') THEN progress.mastered_at
+        WHEN @status = 'mastered' THEN CAST(@at AS timestamptz)
+    END
+RETURNING *`, map[string]any{
+		"user": userID, "entity": entity, "item": itemID, "status": status, "seconds": seconds, "at": at,
+	}).Scan(&p).Error
+	return p, err
+}
+
+// visibleItem — существует ли запись name и видна ли она клиенту
+func visibleItem(c *gin.Context, name string, id int) (bool, error) {
+	var n int64
+	err := visibleTo(c, name, DB.Table(name)).Where(name+".id = ? AND "+name+".deleted_at IS NULL", id).Count(&n).Error
+	return n > 0, err
+}
+
+// ProgressInput — тело POST /api/progress
+type ProgressInput struct {
+	Entity  string `json:"entity"`
+	ItemID  int    `json:"item_id"`
+	Status  string `json:"status"`
+	Seconds int    `json:"seconds"`
+}
+
+func (in ProgressInput) validate(v *validator) {
+	v.oneOf("entity", in.Entity, progressEntities, false)
+	if in.Entity == "words" && in.Status != "" {
+		v.add("status", codeInvalid, "word status is set by reviews")
+	} else {
+		v.oneOf("status", in.Status, progressStatuses, true)
+	}
+	if in.Seconds < 0 || in.Seconds > maxSessionSeconds {
+		v.add("seconds", codeInvalid, "must be between 0 and %d", maxSessionSeconds)
+	}
+	if !v.failed() {
+		v.ref("item_id", in.Entity, in.ItemID, false)
+	}
+}
+
+// PostProgress — POST /api/progress: отметка, что пользователь открыл запись, сколько
+// с ней провёл и (для текстов и правил) насколько её освоил
+func PostProgress(c *gin.Context) {
+	var in ProgressInput
+	if !bindJSON(c, &in) {
+		return
+	}
+	if !validate(c, in) {
+		return
+	}
+	ok, err := visibleItem(c, in.Entity, in.ItemID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !ok {
+		v := newValidator()
+		v.add("item_id", codeNotFound, "%d does not exist", in.ItemID)
+		v.respond(c)
+		return
+	}
+	p, err := saveProgress(DB, currentUser(c).UserID(), in.Entity, in.ItemID, in.Status, in.Seconds, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, p)
+}
+
+// GetProgress — GET /api/progress?entity=&status=: прогресс пользователя по записям
+func GetProgress(c *gin.Context) {
+	db := DB.Where("user_id = ?", currentUser(c).UserID())
+	if v := c.Query("entity"); v != "" {
+		if !slices.Contains(progressEntities, v) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("entity must be one of %v", progressEntities)})
+			return
+		}
+		db = db.Where("entity = ?", v)
+	}
+	if v := c.Query("status"); v != "" {
+		if !slices.Contains(progressStatuses, v) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("status must be one of %v", progressStatuses)})
+			return
+		}
+		db = db.Where("status = ?", v)
+	}
+	list := []Progress{}
+	if err := db.Order("entity, item_id").Find(&list).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+// ProgressCounts — сколько записей доступно и сколько из них на каждом этапе
+type ProgressCounts struct {
+	Total     int64 `json:"total"`
+	Seen      int64 `json:"seen"`
+	Learning  int64 `json:"learning"`
+	Mastered  int64 `json:"mastered"`
+	TimeSpent int64 `json:"time_spent"`
+}
+
+const progressCountsSelect = "count(*) AS total" +
+	", count(*) FILTER (WHERE p.status = 'seen') AS seen" +
+	", count(*) FILTER (WHERE p.status = 'learning') AS learning" +
+	", count(*) FILTER (WHERE p.status = 'mastered') AS mastered" +
+	", coalesce(sum(p.time_spent), 0) AS time_spent"
+
+// progressCounts — запрос к видимым записям name с прогрессом текущего пользователя (алиас p).
+// Записи в корзине и скрытые от клиента не считаются ни в total, ни в прогрессе
+func progressCounts(c *gin.Context, name string) *gorm.DB {
+	db := DB.Table(name).
+		Joins("LEFT JOIN progress p ON p.user_id = ? AND p.entity = ? AND p.item_id = "+name+".id",
+			currentUser(c).UserID(), name).
+		Where(name + ".deleted_at IS NULL")
+	return visibleTo(c, name, db)
+}
+
+// GetProgressSummary — GET /api/progress/summary: итоги по словам, текстам и правилам
+func GetProgressSummary(c *gin.Context) {
+	res := make(map[string]ProgressCounts, len(progressEntities))
+	for _, name := range progressEntities {
+		var counts ProgressCounts
+		if err := progressCounts(c, name).Select(progressCountsSelect).Scan(&counts).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		res[name] = counts
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// CategoryProgress — прогресс по категории; completion — доля выученного
+type CategoryProgress struct {
+	Category   Category `json:"category"`
+	Completion float64  `json:"completion"`
+	ProgressCounts
+}
+
+// GetCategoryProgress — GET /api/progress/categories?entity=words|texts
+func GetCategoryProgress(c *gin.Context) {
+	name := c.DefaultQuery("entity", "words")
+	if name != "words" && name != "texts" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "entity must be words or texts"})
+		return
+	}
+	var rows []struct {
+		CategoryID int
+		ProgressCounts
+	}
+	err := progressCounts(c, name).
+		Select(name + ".category_id, " + progressCountsSelect).
+		Where(name + ".category_id IS NOT NULL").
+		Group(name + ".category_id").Order(name + ".category_id").
+		Scan(&rows).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ids := make([]int, len(rows))
+	for i, r := range rows {
+		ids[i] = r.CategoryID
+	}
+	var cats []Category
+	if len(ids) > 0 {
+		if err := DB.Preload("Translations").Find(&cats, ids).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	byID := make(map[int]Category, len(cats))
+	for _, cat := range cats {
+		byID[cat.ID] = cat
+	}
+
+	res := make([]CategoryProgress, 0, len(rows))
+	for _, r := range rows {
+		cat, ok := byID[r.CategoryID]
+		if !ok {
+			continue // категория в корзине
+		}
+		item := CategoryProgress{Category: cat, ProgressCounts: r.ProgressCounts}
+		if r.Total > 0 {
+			item.Completion = float64(r.Mastered) / float64(r.Total)
+		}
+		res = append(res, item)
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// LanguageProgress — прогресс по записям, у которых есть перевод на язык
+type LanguageProgress struct {
+	Lang         string         `json:"lang"`
+	Words        ProgressCounts `json:"words"`
+	Texts        ProgressCounts `json:"texts"`
+	GrammarRules ProgressCounts `json:"grammar_rules"`
+}
+
+// GetLanguageProgress — GET /api/progress/languages
+func GetLanguageProgress(c *gin.Context) {
+	byLang := map[string]*LanguageProgress{}
+	for _, name := range progressEntities {
+		tr := progressLangFields[name]
+		var rows []struct {
+			Lang string
+			ProgressCounts
+		}
+		err := progressCounts(c, name).
+			Joins(fmt.Sprintf("JOIN %s t ON t.%s = %s.id AND t.%s <> ''", tr[0], entities[name].trFK, name, tr[1])).
+			Select("t.lang, " + progressCountsSelect).
+			Group("t.lang").
+			Scan(&rows).Error
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		for _, r := range rows {
+			lp, ok := byLang[r.Lang]
+			if !ok {
+				lp = &LanguageProgress{Lang: r.Lang}
+				byLang[r.Lang] = lp
+			}
+			switch name {
+			case "words":
+				lp.Words = r.ProgressCounts
+			case "texts":
+				lp.Texts = r.ProgressCounts
+			case "grammar_rules":
+				lp.GrammarRules = r.ProgressCounts
+			}
+		}
+	}
+	res := make([]LanguageProgress, 0, len(byLang))
+	for _, lp := range byLang {
+		res = append(res, *lp)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Lang < res[j].Lang })
+	c.JSON(http.StatusOK, res)
+}
+
+// DailyProgress — выученные слова и ответы на карточки за день
+type DailyProgress struct {
+	Date     string `json:"date"`
+	Mastered int64  `json:"mastered"`
+	Reviews  int64  `json:"reviews"`
+}
+
+// GetDailyProgress — GET /api/progress/daily?days=30&tz=Europe/Berlin: по дням, включая пустые.
+// Дни считаются в часовом поясе tz (по умолчанию UTC)
+func GetDailyProgress(c *gin.Context) {
+	days := defaultStatsDays
+	if v := c.Query("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxStatsDays {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("days must be between 1 and %d", maxStatsDays)})
+			return
+		}
+		days = n
+	}
+	loc, err := time.LoadLocation(c.DefaultQuery("tz", "UTC"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unknown tz"})
+		return
+	}
+	userID := currentUser(c).UserID()
+	now := time.Now().In(loc)
+	from := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc).AddDate(0, 0, 1-days)
+
+	perDay := func(sql string) (map[string]int64, error) {
+		var rows []struct {
+			Day string
+			N   int64
+		}
+		if err := DB.Raw(sql, loc.String(), userID, from).Scan(&rows).Error; err != nil {
+			return nil, err
+		}
+		m := make(map[string]int64, len(rows))
+		for _, r := range rows {
+			m[r.Day] = r.N
+		}
+		return m, nil
+	}
+	mastered, err := perDay(`SELECT to_char(mastered_at AT TIME ZONE ?, 'YYYY-MM-DD') AS day, count(*) AS n
+FROM progress WHERE user_id = ? AND entity = 'words' AND mastered_at >= ? GROUP BY day`)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	reviews, err := perDay(`SELECT to_char(reviewed_at AT TIME ZONE ?, 'YYYY-MM-DD') AS day, count(*) AS n
+FROM review_log WHERE user_id = ? AND reviewed_at >= ? GROUP BY day`)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	res := make([]DailyProgress, days)
+	for i := range res {
+		day := from.AddDate(0, 0, i).Format("2006-01-02")
+		res[i] = DailyProgress{Date: day, Mastered: mastered[day], Reviews: reviews[day]}
+	}
+	c.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,343 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"bd_back_for_translate_app/languages"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultPageSize = 100
+	maxPageSize     = 1000
+)
+
+type sortKind int
+
+const (
+	sortInt sortKind = iota
+	sortText
+)
+
+// sortField — SQL-выражение, по которому можно сортировать и строить курсор.
+// Выражение не должно давать NULL, иначе сравнение в курсоре теряет строки.
+type sortField struct {
+	expr string
+	kind sortKind
+}
+
+// translatedSort — сортировка по переводу: поле <prefix>_<lang> берётся из таблицы переводов
+type translatedSort struct {
+	prefix string
+	table  string
+	fk     string
+	column string
+}
+
+// listFilter добавляет условие для значений параметра (?param=a,b)
+type listFilter func(db *gorm.DB, values []string) (*gorm.DB, error)
+
+// listSpec описывает, как фильтровать, сортировать и листать список одной сущности
+type listSpec struct {
+	table      string
+	sorts      map[string]sortField
+	translated []translatedSort
+	filters    map[string]listFilter
+}
+
+func (s *listSpec) sortField(name string) (sortField, bool) {
+	if name == "id" {
+		return sortField{expr: s.table + ".id", kind: sortInt}, true
+	}
+	if f, ok := s.sorts[name]; ok {
+		return f, true
+	}
+	for _, t := range s.translated {
+		lang, ok := strings.CutPrefix(name, t.prefix+"_")
+		if !ok || !languages.Supported(lang) {
+			continue
+		}
+		// код языка проверен по реестру, поэтому его можно подставить в SQL
+		expr := fmt.Sprintf("COALESCE((SELECT tr.%s FROM %s tr WHERE tr.%s = %s.id AND tr.lang = '%s'), '')",
+			t.column, t.table, t.fk, s.table, lang)
+		return sortField{expr: expr, kind: sortText}, true
+	}
+	return sortField{}, false
+}
+
+type sortTerm struct {
+	sortField
+	desc bool
+}
+
+// listQuery — разобранные параметры ?limit=&after=&sort=&<фильтры>
+type listQuery struct {
+	spec    *listSpec
+	limit   int
+	order   []sortTerm
+	after   []any
+	filters []func(db *gorm.DB) (*gorm.DB, error)
+}
+
+func parseListQuery(c *gin.Context, spec *listSpec) (*listQuery, error) {
+	q := &listQuery{spec: spec, limit: defaultPageSize}
+
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxPageSize {
+			return nil, fmt.Errorf("limit must be between 1 and %d", maxPageSize)
+		}
+		q.limit = n
+	}
+
+	hasID := false
+	if v := c.Query("sort"); v != "" {
+		for _, name := range strings.Split(v, ",") {
+			name = strings.TrimSpace(name)
+			desc := strings.HasPrefix(name, "-")
+			name = strings.TrimPrefix(name, "-")
+			f, ok := spec.sortField(name)
+			if !ok {
+				return nil, fmt.Errorf("unknown sort field %q", name)
+			}
+			q.order = append(q.order, sortTerm{sortField: f, desc: desc})
+			hasID = hasID || name == "id"
+		}
+	}
+	// id в конце делает порядок однозначным, без этого курсор может пропускать строки
+	if !hasID {
+		f, _ := spec.sortField("id")
+		q.order = append(q.order, sortTerm{sortField: f})
+	}
+
+	for param, filter := range spec.filters {
+		v := c.Query(param)
+		if v == "" {
+			continue
+		}
+		values := strings.Split(v, ",")
+		q.filters = append(q.filters, func(db *gorm.DB) (*gorm.DB, error) {
+			db, err := filter(db, values)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", param, err)
+			}
+			return db, nil
+		})
+	}
+
+	if v := c.Query("after"); v != "" {
+		after, err := decodeCursor(v, q.order)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cursor")
+		}
+		q.after = after
+	}
+	return q, nil
+}
+
+func (q *listQuery) applyFilters(db *gorm.DB) (*gorm.DB, error) {
+	var err error
+	for _, f := range q.filters {
+		if db, err = f(db); err != nil {
+			return nil, err
+		}
+	}
+	return db, nil
+}
+
+// applyCursor оставляет строки строго после курсора в порядке сортировки:
+// (a > va) OR (a = va AND b > vb) OR ...
+func (q *listQuery) applyCursor(db *gorm.DB) *gorm.DB {
+	if q.after == nil {
+		return db
+	}
+	var (
+		ors  []string
+		args []any
+	)
+	for i, term := range q.order {
+		var parts []string
+		for j := 0; j < i; j++ {
+			parts = append(parts, q.order[j].expr+" = ?")
+			args = append(args, q.after[j])
+		}
+		op := ">"
+		if term.desc {
+			op = "<"
+		}
+		parts = append(parts, term.expr+" "+op+" ?")
+		args = append(args, q.after[i])
+		ors = append(ors, "("+strings.Join(parts, " AND ")+")")
+	}
+	return db.Where("("+strings.Join(ors, " OR ")+")", args...)
+}
+
+func (q *listQuery) orderBy() string {
+	parts := make([]string, len(q.order))
+	for i, term := range q.order {
+		parts[i] = term.expr
+		if term.desc {
+			parts[i] += " DESC"
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// findPage выбирает одну страницу списка в dest и выставляет заголовки
+// X-Total-Count (с учётом фильтров) и X-Next-Cursor (если есть следующая страница).
+// db — какие записи видны клиенту, по нему же считается X-Total-Count;
+// load — как загружать страницу (колонки, переводы).
+// При ошибке ответ уже записан и возвращается false.
+func findPage[T any](c *gin.Context, spec *listSpec, db *gorm.DB, load func(*gorm.DB) *gorm.DB, dest *[]T) bool {
+	q, err := parseListQuery(c, spec)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+	db = db.Session(&gorm.Session{})
+
+	countDB, err := q.applyFilters(db.Model(new(T)))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+	var total int64
+	if err := countDB.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return false
+	}
+
+	pageDB, _ := q.applyFilters(load(db))
+	pageDB = q.applyCursor(pageDB).Order(q.orderBy()).Limit(q.limit + 1)
+	if err := pageDB.Find(dest).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return false
+	}
+
+	c.Header("X-Total-Count", strconv.FormatInt(total, 10))
+	if len(*dest) > q.limit {
+		*dest = (*dest)[:q.limit]
+		// у всех моделей первичный ключ — поле ID
+		lastID := reflect.ValueOf((*dest)[q.limit-1]).FieldByName("ID").Int()
+		cursor, err := q.cursorFor(lastID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return false
+		}
+		c.Header("X-Next-Cursor", cursor)
+	}
+	return true
+}
+
+// cursorFor читает значения ключей сортировки последней строки и кодирует их в курсор
+func (q *listQuery) cursorFor(id int64) (string, error) {
+	exprs := make([]string, len(q.order))
+	dests := make([]any, len(q.order))
+	for i, term := range q.order {
+		exprs[i] = term.expr
+		switch term.kind {
+		case sortInt:
+			dests[i] = new(int64)
+		default:
+			dests[i] = new(string)
+		}
+	}
+	row := DB.Table(q.spec.table).
+		Select(strings.Join(exprs, ", ")).
+		Where(q.spec.table+".id = ?", id).
+		Row()
+	if err := row.Scan(dests...); err != nil {
+		return "", err
+	}
+
+	values := make([]any, len(dests))
+	for i, d := range dests {
+		values[i] = reflect.ValueOf(d).Elem().Interface()
+	}
+	data, err := json.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeCursor(s string, order []sortTerm) ([]any, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	if len(raw) != len(order) {
+		return nil, fmt.Errorf("cursor does not match sort")
+	}
+
+	values := make([]any, len(raw))
+	for i, term := range order {
+		switch term.kind {
+		case sortInt:
+			var n int64
+			if err := json.Unmarshal(raw[i], &n); err != nil {
+				return nil, err
+			}
+			values[i] = n
+		default:
+			var s string
+			if err := json.Unmarshal(raw[i], &s); err != nil {
+				return nil, err
+			}
+			values[i] = s
+		}
+	}
+	return values, nil
+}
+
+// intFilter — column IN (значения), значения должны быть целыми
+func intFilter(column string) listFilter {
+	return func(db *gorm.DB, values []string) (*gorm.DB, error) {
+		ids := make([]int, 0, len(values))
+		for _, v := range values {
+			n, err := strconv.Atoi(strings.TrimSpace(v))
+			if err != nil {
+				return nil, fmt.Errorf("invalid integer %q", v)
+			}
+			ids = append(ids, n)
+		}
+		return db.Where(column+" IN ?", ids), nil
+	}
+}
+
+// textFilter — column IN (значения)
+func textFilter(column string) listFilter {
+	return func(db *gorm.DB, values []string) (*gorm.DB, error) {
+		for i := range values {
+			values[i] = strings.TrimSpace(values[i])
+		}
+		return db.Where(column+" IN ?", values), nil
+	}
+}
+
+// languageFilter — у сущности есть непустой перевод хотя бы на один из языков
+func languageFilter(owner, table, fk, column string) listFilter {
+	return func(db *gorm.DB, values []string) (*gorm.DB, error) {
+		for i, v := range values {
+			values[i] = strings.TrimSpace(v)
+			if !languages.Supported(values[i]) {
+				return nil, fmt.Errorf("unsupported language %q", v)
+			}
+		}
+		return db.Where(fmt.Sprintf(
+			"EXISTS (SELECT 1 FROM %s tr WHERE tr.%s = %s.id AND tr.lang IN ? AND tr.%s <> '')",
+			table, fk, owner, column), values), nil
+	}
+}
@@ -0,0 +1,318 @@
+package handlers
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"bd_back_for_translate_app/languages"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultQuizQuestions = 10
+	maxQuizQuestions     = 50
+	quizOptions          = 4 // правильный ответ и три отвлекающих
+)
+
+// quizPOSLangs — языки, по полю type которых сравнивается часть речи
+var quizPOSLangs = []string{"en", "de"}
+
+// quizArticles — артикли, которые не делают ответы разными («das Haus» и «Haus»)
+var quizArticles = map[string][]string{
+	"de": {"der", "die", "das", "ein", "eine"},
+	"en": {"the", "a", "an", "to"},
+}
+
+// QuizQuestion — вопрос: слово на from и варианты перевода на to
+type QuizQuestion struct {
+	WordID        int      `json:"word_id"`
+	Prompt        string   `json:"prompt"`
+	Transcription string   `json:"transcription,omitempty"`
+	Options       []string `json:"options"`
+}
+
+// Quiz — ответ GET /api/quizzes/vocabulary
+type Quiz struct {
+	CategoryID int            `json:"category_id"`
+	From       string         `json:"from"`
+	To         string         `json:"to"`
+	Questions  []QuizQuestion `json:"questions"`
+}
+
+// quizWord — слово категории с переводом на to
+type quizWord struct {
+	id     int
+	src    *WordTranslation // nil, если перевода на from нет: такое слово годится только в отвлекающие
+	answer string
+	types  map[string]string // часть речи по языкам quizPOSLangs
+}
+
+// GetVocabularyQuiz — GET /api/quizzes/vocabulary?category_id=&from=ru&to=de&n=10: вопросы
+// с выбором ответа по словам категории. Отвлекающие варианты берутся из той же категории
+// и той же части речи; если таких не хватает — из остальных слов категории
+func GetVocabularyQuiz(c *gin.Context) {
+	categoryID, err := strconv.Atoi(c.Query("category_id"))
+	if err != nil || categoryID < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "category_id is required"})
+		return
+	}
+	from, to := c.Query("from"), c.Query("to")
+	if !languages.Supported(from) || !languages.Supported(to) || from == to {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from and to must be two different supported languages"})
+		return
+	}
+	n := defaultQuizQuestions
+	if v := c.Query("n"); v != "" {
+		n, err = strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxQuizQuestions {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("n must be between 1 and %d", maxQuizQuestions)})
+			return
+		}
+	}
+
+	if err := DB.First(&Category{}, categoryID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "category not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	var words []Word
+	if err := visibleTo(c, "words", DB.Preload("Translations")).Where("category_id = ?", categoryID).Find(&words).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var pool []quizWord
+	for _, w := range words {
+		qw := quizWord{id: w.ID, types: map[string]string{}}
+		for i := range w.Translations {
+			t := &w.Translations[i]
+			if t.Lang == from && t.Word != "" {
+				qw.src = t
+			}
+			if t.Lang == to {
+				qw.answer = strings.TrimSpace(t.Word)
+			}
+			if slices.Contains(quizPOSLangs, t.Lang) && t.Type != "" {
+				qw.types[t.Lang] = strings.ToLower(strings.TrimSpace(t.Type))
+			}
+		}
+		if qw.answer != "" {
+			pool = append(pool, qw)
+		}
+	}
+
+	quiz := Quiz{CategoryID: categoryID, From: from, To: to, Questions: []QuizQuestion{}}
+	for _, i := range rand.Perm(len(pool)) {
+		if len(quiz.Questions) == n {
+			break
+		}
+		w := pool[i]
+		if w.src == nil {
+			continue
+		}
+		options := append([]string{w.answer}, quizDistractors(w, pool, to)...)
+		if len(options) < 2 {
+			continue // в категории нечем отвлекать
+		}
+		rand.Shuffle(len(options), func(a, b int) { options[a], options[b] = options[b], options[a] })
+		quiz.Questions = append(quiz.Questions, QuizQuestion{
+			WordID:        w.id,
+			Prompt:        w.src.Word,
+			Transcription: w.src.Transcription,
+			Options:       options,
+		})
+	}
+	c.JSON(http.StatusOK, quiz)
+}
+
+// quizDistractors подбирает до quizOptions-1 неправильных ответов к слову w: сначала той же
+// части речи, потом без указанной части речи, потом остальные. Варианты, похожие на
+// правильный ответ или друг на друга, пропускаются
+func quizDistractors(w quizWord, pool []quizWord, lang string) []string {
+	var tiers [3][]string
+	for _, o := range pool {
+		if o.id == w.id {
+			continue
+		}
+		tier := 2
+		if same, known := samePOS(w, o); same {
+			tier = 0
+		} else if !known {
+			tier = 1
+		}
+		tiers[tier] = append(tiers[tier], o.answer)
+	}
+
+	picked := []string{}
+	taken := []string{normalizeAnswer(lang, w.answer)}
+	for _, tier := range tiers {
+		rand.Shuffle(len(tier), func(a, b int) { tier[a], tier[b] = tier[b], tier[a] })
+		for _, answer := range tier {
+			if len(picked) == quizOptions-1 {
+				return picked
+			}
+			norm := normalizeAnswer(lang, answer)
+			if slices.ContainsFunc(taken, func(t string) bool { return nearDuplicate(t, norm) }) {
+				continue
+			}
+			taken = append(taken, norm)
+			picked = append(picked, answer)
+		}
+	}
+	return picked
+}
+
+// samePOS сравнивает части речи по первому языку, где они указаны у обоих слов;
+// known = false, если сравнить не по чему
+func samePOS(a, b quizWord) (same, known bool) {
+	for _, lang := range quizPOSLangs {
+		ta, tb := a.types[lang], b.types[lang]
+		if ta != "" && tb != "" {
+			return ta == tb, true
+		}
+	}
+	return false, false
+}
+
+// normalizeAnswer — ответ без регистра, лишних пробелов и начального артикля
+func normalizeAnswer(lang, s string) string {
+	fields := strings.Fields(strings.ToLower(s))
+	if len(fields) > 1 && slices.Contains(quizArticles[lang], fields[0]) {
+		fields = fields[1:]
+	}
+	return strings.Join(fields, " ")
+}
+
+// nearDuplicate — ответы совпадают или отличаются одной буквой (color/colour);
+// у коротких слов одна буква уже меняет слово (Hund/Mund), поэтому там нужен точный повтор
+func nearDuplicate(a, b string) bool {
+	if a == b {
+		return true
+	}
+	if min(len([]rune(a)), len([]rune(b))) < 5 {
+		return false
+	}
+	return levenshtein(a, b) <= 1
+}
+
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
+
+// QuizAnswer — выбранный вариант по слову
+type QuizAnswer struct {
+	WordID int    `json:"word_id"`
+	Answer string `json:"answer"`
+}
+
+// QuizSubmission — тело POST /api/quizzes/vocabulary
+type QuizSubmission struct {
+	To      string       `json:"to"`
+	Answers []QuizAnswer `json:"answers"`
+}
+
+func (in QuizSubmission) validate(v *validator) {
+	if in.To == "" {
+		v.add("to", codeRequired, "is required")
+	}
+	v.language("to", in.To)
+	if len(in.Answers) == 0 {
+		v.add("answers", codeRequired, "is required")
+	}
+	if len(in.Answers) > maxQuizQuestions {
+		v.add("answers", codeInvalid, "at most %d answers", maxQuizQuestions)
+	}
+	for i, a := range in.Answers {
+		if a.WordID == 0 {
+			v.add(fmt.Sprintf("answers[%d].word_id", i), codeRequired, "is required")
+		}
+	}
+}
+
+// QuizResult — проверка одного ответа
+type QuizResult struct {
+	WordID   int    `json:"word_id"`
+	Answer   string `json:"answer"`
+	Expected string `json:"expected"`
+	Correct  bool   `json:"correct"`
+}
+
+// GradeVocabularyQuiz — POST /api/quizzes/vocabulary: проверка ответов. Ответ засчитывается,
+// если совпадает с переводом слова на to без учёта регистра и артикля
+func GradeVocabularyQuiz(c *gin.Context) {
+	var in QuizSubmission
+	if !bindJSON(c, &in) {
+		return
+	}
+	if !validate(c, in) {
+		return
+	}
+	ids := make([]int, len(in.Answers))
+	for i, a := range in.Answers {
+		ids[i] = a.WordID
+	}
+	var words []Word
+	if err := visibleTo(c, "words", DB.Preload("Translations", "lang = ?", in.To)).Find(&words, ids).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	expected := make(map[int]string, len(words))
+	for _, w := range words {
+		expected[w.ID] = ""
+		for _, t := range w.Translations {
+			expected[w.ID] = strings.TrimSpace(t.Word)
+		}
+	}
+	v := newValidator()
+	for i, a := range in.Answers {
+		if _, ok := expected[a.WordID]; !ok {
+			v.add(fmt.Sprintf("answers[%d].word_id", i), codeNotFound, "%d does not exist", a.WordID)
+		}
+	}
+	if !v.respond(c) {
+		return
+	}
+
+	results := make([]QuizResult, len(in.Answers))
+	correct := 0
+	for i, a := range in.Answers {
+		want := expected[a.WordID]
+		ok := want != "" && normalizeAnswer(in.To, a.Answer) == normalizeAnswer(in.To, want)
+		if ok {
+			correct++
+		}
+		results[i] = QuizResult{WordID: a.WordID, Answer: a.Answer, Expected: want, Correct: ok}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"total":   len(results),
+		"correct": correct,
+		"score":   float64(correct) / float64(len(results)),
+		"results": results,
+	})
+}
@@ -0,0 +1,306 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"bd_back_for_translate_app/srs"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	defaultDueLimit  = 20
+	maxDueLimit      = 100
+	defaultNewWords  = 10
+	defaultRetention = 0.9
+	// reviewClockSkew — насколько reviewed_at может опережать часы сервера
+	reviewClockSkew = time.Minute
+)
+
+// scheduler считает следующие повторения; целевая вероятность вспомнить — SRS_RETENTION
+var scheduler *srs.Scheduler
+
+var errDuplicateReview = errors.New("duplicate review")
+
+// InitReviews настраивает планировщик повторений
+func InitReviews() error {
+	retention := defaultRetention
+	if v := os.Getenv("SRS_RETENTION"); v != "" {
+		r, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return fmt.Errorf("SRS_RETENTION must be a number, got %q", v)
+		}
+		retention = r
+	}
+	s, err := srs.New(retention)
+	if err != nil {
+		return err
+	}
+	scheduler = s
+	return nil
+}
+
+// ReviewState — состояние повторения слова у пользователя
+type ReviewState struct {
+	UserID       int        `gorm:"primaryKey;column:user_id" json:"-"`
+	WordID       int        `gorm:"primaryKey;column:word_id" json:"word_id"`
+	State        string     `gorm:"column:state"              json:"state"`
+	Stability    float64    `gorm:"column:stability"          json:"stability"`
+	Difficulty   float64    `gorm:"column:difficulty"         json:"difficulty"`
+	Reps         int        `gorm:"column:reps"               json:"reps"`
+	Lapses       int        `gorm:"column:lapses"             json:"lapses"`
+	DueAt        time.Time  `gorm:"column:due_at"             json:"due_at"`
+	LastReviewAt *time.Time `gorm:"column:last_review_at"     json:"last_review_at"`
+}
+
+func (ReviewState) TableName() string { return "review_states" }
+
+func (s ReviewState) card() srs.Card {
+	return srs.Card{
+		State:      s.State,
+		Stability:  s.Stability,
+		Difficulty: s.Difficulty,
+		Reps:       s.Reps,
+		Lapses:     s.Lapses,
+		LastReview: s.LastReviewAt,
+		Due:        s.DueAt,
+	}
+}
+
+func (s *ReviewState) apply(c srs.Card) {
+	s.State, s.Stability, s.Difficulty = c.State, c.Stability, c.Difficulty
+	s.Reps, s.Lapses = c.Reps, c.Lapses
+	s.DueAt, s.LastReviewAt = c.Due, c.LastReview
+}
+
+// reviewLog — журнал ответов; id задаёт клиент, чтобы повтор запроса не засчитывался дважды
+type reviewLog struct {
+	ID         string    `gorm:"primaryKey;column:id"`
+	UserID     int       `gorm:"primaryKey;column:user_id"`
+	WordID     int       `gorm:"column:word_id"`
+	Rating     int       `gorm:"column:rating"`
+	State      string    `gorm:"column:state"`
+	Stability  float64   `gorm:"column:stability"`
+	Difficulty float64   `gorm:"column:difficulty"`
+	DueAt      time.Time `gorm:"column:due_at"`
+	ReviewedAt time.Time `gorm:"column:reviewed_at"`
+}
+
+func (reviewLog) TableName() string { return "review_log" }
+
+// DueReview — элемент очереди повторения; Review пуст у нового слова
+type DueReview struct {
+	Word   Word         `json:"word"`
+	Review *ReviewState `json:"review"`
+}
+
+// GetDueReviews — GET /api/reviews/due?limit=&new=&category_id=: слова, которые пора повторить,
+// по сроку, а после них до new ещё не изученных слов
+func GetDueReviews(c *gin.Context) {
+	limit := defaultDueLimit
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxDueLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("limit must be between 1 and %d", maxDueLimit)})
+			return
+		}
+		limit = n
+	}
+	newLimit := defaultNewWords
+	if v := c.Query("new"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 || n > maxDueLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("new must be between 0 and %d", maxDueLimit)})
+			return
+		}
+		newLimit = n
+	}
+	categoryID := 0
+	if v := c.Query("category_id"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid category_id"})
+			return
+		}
+		categoryID = n
+	}
+	userID := currentUser(c).UserID()
+
+	// слова из корзины и снятые с публикации в очередь не попадают, но их состояние сохраняется
+	words := func(db *gorm.DB) *gorm.DB {
+		db = visibleTo(c, "words", db.Where("words.deleted_at IS NULL"))
+		if categoryID != 0 {
+			db = db.Where("words.category_id = ?", categoryID)
+		}
+		return db
+	}
+
+	states := []ReviewState{}
+	err := DB.Model(&ReviewState{}).Select("review_states.*").
+		Joins("JOIN words ON words.id = review_states.word_id").
+		Scopes(words).
+		Where("review_states.user_id = ? AND review_states.due_at <= now()", userID).
+		Order("review_states.due_at, review_states.word_id").Limit(limit).
+		Find(&states).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ids := make([]int, 0, limit)
+	for _, s := range states {
+		ids = append(ids, s.WordID)
+	}
+	if n := min(newLimit, limit-len(states)); n > 0 {
+		var fresh []int
+		err := DB.Model(&Word{}).Scopes(words).
+			Where("NOT EXISTS (SELECT 1 FROM review_states rs WHERE rs.user_id = ? AND rs.word_id = words.id)", userID).
+			Order("words.id").Limit(n).Pluck("words.id", &fresh).Error
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		ids = append(ids, fresh...)
+	}
+
+	items := make([]DueReview, 0, len(ids))
+	if len(ids) > 0 {
+		var list []Word
+		if err := wordProjectionNoAudio().apply(DB).Find(&list, ids).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		byID := make(map[int]Word, len(list))
+		for _, w := range list {
+			byID[w.ID] = w
+		}
+		for i, id := range ids {
+			item := DueReview{Word: byID[id]}
+			if i < len(states) {
+				item.Review = &states[i]
+			}
+			items = append(items, item)
+		}
+	}
+	c.JSON(http.StatusOK, items)
+}
+
+// ReviewInput — тело POST /api/reviews. id — ключ идемпотентности от клиента,
+// reviewed_at — когда ответ дан на устройстве (для повторений, накопленных офлайн)
+type ReviewInput struct {
+	ID         string     `json:"id"`
+	WordID     int        `json:"word_id"`
+	Rating     int        `json:"rating"`
+	ReviewedAt *time.Time `json:"reviewed_at"`
+	Seconds    int        `json:"seconds"` // сколько пользователь думал над карточкой
+}
+
+func (in ReviewInput) validate(v *validator) {
+	v.maxLen("id", in.ID, maxShortText)
+	if !srs.Rating(in.Rating).Valid() {
+		v.add("rating", codeInvalid, "must be between %d and %d", srs.Again, srs.Easy)
+	}
+	if in.ReviewedAt != nil && in.ReviewedAt.After(time.Now().Add(reviewClockSkew)) {
+		v.add("reviewed_at", codeInvalid, "must not be in the future")
+	}
+	if in.Seconds < 0 || in.Seconds > maxSessionSeconds {
+		v.add("seconds", codeInvalid, "must be between 0 and %d", maxSessionSeconds)
+	}
+	v.ref("word_id", "words", in.WordID, false)
+}
+
+// PostReview — POST /api/reviews: ответ на карточку. Следующую дату повторения считает сервер,
+// поэтому прогресс общий для всех устройств; повтор запроса с тем же id возвращает текущее состояние
+func PostReview(c *gin.Context) {
+	var in ReviewInput
+	if !bindJSON(c, &in) {
+		return
+	}
+	if !validate(c, in) {
+		return
+	}
+	ok, err := visibleItem(c, "words", in.WordID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !ok {
+		v := newValidator()
+		v.add("word_id", codeNotFound, "%d does not exist", in.WordID)
+		v.respond(c)
+		return
+	}
+	if in.ID == "" {
+		b := make([]byte, 16)
+		if _, err := rand.Read(b); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		in.ID = hex.EncodeToString(b)
+	}
+
+	userID := currentUser(c).UserID()
+	state := ReviewState{UserID: userID, WordID: in.WordID, State: srs.StateNew}
+	err = DB.Transaction(func(tx *gorm.DB) error {
+		// блокировка строки упорядочивает ответы с разных устройств на одно слово
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ? AND word_id = ?", userID, in.WordID).First(&state).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		at := time.Now()
+		if in.ReviewedAt != nil && in.ReviewedAt.Before(at) {
+			at = *in.ReviewedAt
+		}
+		// ответ, данный раньше уже учтённого, применяется так, будто дан сразу после него
+		if state.LastReviewAt != nil && at.Before(*state.LastReviewAt) {
+			at = *state.LastReviewAt
+		}
+		state.apply(scheduler.Review(state.card(), srs.Rating(in.Rating), at))
+
+		res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&reviewLog{
+			ID:         in.ID,
+			UserID:     userID,
+			WordID:     in.WordID,
+			Rating:     in.Rating,
+			State:      state.State,
+			Stability:  state.Stability,
+			Difficulty: state.Difficulty,
+			DueAt:      state.DueAt,
+			ReviewedAt: at,
+		})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errDuplicateReview
+		}
+		if err := tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(&state).Error; err != nil {
+			return err
+		}
+		_, err = saveProgress(tx, userID, "words", in.WordID, wordStatus(state), in.Seconds, at)
+		return err
+	})
+	if errors.Is(err, errDuplicateReview) {
+		var logged reviewLog
+		if err = DB.Where("user_id = ? AND id = ?", userID, in.ID).First(&logged).Error; err == nil {
+			state = ReviewState{}
+			err = DB.Where("user_id = ? AND word_id = ?", userID, logged.WordID).First(&state).Error
+		}
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, state)
+}
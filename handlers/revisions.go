@@ -0,0 +1,454 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+
+	"bd_back_for_translate_app/languages"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// snapshotJSON — JSON-снимок записи в колонке jsonb; nil — записи нет
+type snapshotJSON []byte
+
+func (s snapshotJSON) Value() (driver.Value, error) {
+	if s == nil {
+		return nil, nil
+	}
+	return string(s), nil
+}
+
+func (s *snapshotJSON) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*s = nil
+	case []byte:
+		*s = append(snapshotJSON(nil), v...)
+	case string:
+		*s = snapshotJSON(v)
+	default:
+		return fmt.Errorf("snapshotJSON: unsupported type %T", src)
+	}
+	return nil
+}
+
+func (s snapshotJSON) MarshalJSON() ([]byte, error) {
+	if s == nil {
+		return []byte("null"), nil
+	}
+	return s, nil
+}
+
+// Revision — одна правка записи: снимки до и после, кто и когда
+type Revision struct {
+	ID         int64        `gorm:"primaryKey;column:id"  json:"id"`
+	EntityType string       `gorm:"column:entity_type"    json:"entity_type"`
+	EntityID   int          `gorm:"column:entity_id"      json:"entity_id"`
+	Action     string       `gorm:"column:action"         json:"action"`
+	Before     snapshotJSON `gorm:"column:before"         json:"before,omitempty"`
+	After      snapshotJSON `gorm:"column:after"          json:"after,omitempty"`
+	Actor      string       `gorm:"column:actor"          json:"actor"`
+	CreatedAt  time.Time    `gorm:"column:created_at"     json:"created_at"`
+}
+
+func (Revision) TableName() string { return "revisions" }
+
+const (
+	actionCreate  = "create"
+	actionUpdate  = "update"
+	actionDelete  = "delete"
+	actionRestore = "restore"
+	actionRevert  = "revert"
+)
+
+// anonymousActor — автор правки без пользователя и ключа
+const anonymousActor = "anonymous"
+
+// actorOf — кто делает правку: email пользователя из токена или apikey:<имя ключа>.
+// Заголовкам запроса не доверяем: автор берётся только из проверенных сервером данных
+func actorOf(c *gin.Context) string {
+	if claims := currentUser(c); claims != nil {
+		return claims.Email
+	}
+	if key := currentAPIKey(c); key != nil {
+		return "apikey:" + key.Name
+	}
+	return anonymousActor
+}
+
+// snapshot — запись name/id в JSON API. Клипы не сохраняются: вместо audio_<lang>
+// пишется ключ клипа audio_id_<lang>, по нему откат возвращает ту же озвучку
+func snapshot(tx *gorm.DB, name string, id int) (snapshotJSON, error) {
+	items, err := entities[name].load(tx, []int{id})
+	if err != nil {
+		return nil, err
+	}
+	obj, ok := items[id]
+	if !ok {
+		return nil, nil
+	}
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	m := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	// updated_at меняется при любой записи и только зашумил бы разницу
+	delete(m, "updated_at")
+
+	if entities[name].audioTable != "" {
+		for _, lang := range languages.Codes() {
+			delete(m, "audio_"+lang)
+		}
+		list := reflect.ValueOf(obj).FieldByName("Translations")
+		for i := 0; i < list.Len(); i++ {
+			tr := reflect.New(list.Type().Elem())
+			tr.Elem().Set(list.Index(i))
+			audioID, _ := tr.Interface().(audioTranslation).audioFields()
+			if *audioID != nil {
+				m["audio_id_"+tr.Interface().(translation).language()], _ = json.Marshal(**audioID)
+			}
+		}
+	}
+	return json.Marshal(m)
+}
+
+// recordRevision пишет ревизию записи name/id; снимок «после» берётся из tx
+func recordRevision(tx *gorm.DB, actor, name string, id int, action string, before snapshotJSON) error {
+	var after snapshotJSON
+	if action != actionDelete {
+		var err error
+		if after, err = snapshot(tx, name, id); err != nil {
+			return err
+		}
+	}
+	return tx.Create(&Revision{
+		EntityType: name,
+		EntityID:   id,
+		Action:     action,
+		Before:     before,
+		After:      after,
+		Actor:      actor,
+	}).Error
+}
+
+// withRevision выполняет запись fn в транзакции вместе с ревизией.
+// Для create id заполняет fn, снимка «до» у новой записи нет
+func withRevision(actor, name string, id *int, action string, fn func(tx *gorm.DB) error) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		var before snapshotJSON
+		if action != actionCreate {
+			var err error
+			if before, err = snapshot(tx, name, *id); err != nil {
+				return err
+			}
+		}
+		if err := fn(tx); err != nil {
+			return err
+		}
+		return recordRevision(tx, actor, name, *id, action, before)
+	})
+}
+
+// applySnapshot записывает в name/id состояние из снимка: поля сущности и переводы целиком.
+// version — текущая версия записи, она проверяется и увеличивается
+func applySnapshot(tx *gorm.DB, name string, id, version int, data snapshotJSON) error {
+	t := entities[name]
+	raw := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	obj := t.model()
+	if err := json.Unmarshal(data, obj); err != nil {
+		return err
+	}
+
+	v := reflect.ValueOf(obj).Elem()
+	v.FieldByName("ID").SetInt(int64(id))
+	v.FieldByName("Version").SetInt(int64(version + 1))
+	list := v.FieldByName("Translations")
+	for i := 0; i < list.Len(); i++ {
+		tr := list.Index(i).Addr().Interface()
+		tr.(translation).setOwner(id)
+		at, ok := tr.(audioTranslation)
+		if !ok {
+			continue
+		}
+		var audioID string
+		if json.Unmarshal(raw["audio_id_"+tr.(translation).language()], &audioID) != nil || audioID == "" {
+			continue
+		}
+		// клип могли вычистить из хранилища вместе с корзиной; под lockAudio его не удалят до коммита
+		if err := lockAudio(tx, audioID); err != nil {
+			return err
+		}
+		if AudioStore != nil {
+			if exists, err := AudioStore.Exists(context.Background(), audioID); err != nil || !exists {
+				continue
+			}
+		}
+		idp, _ := at.audioFields()
+		*idp = &audioID
+	}
+
+	if err := bumpVersion(tx, name, id, version); err != nil {
+		return err
+	}
+	if err := tx.Omit(clause.Associations).Save(obj).Error; err != nil {
+		return err
+	}
+	if err := tx.Where(t.trFK+" = ?", id).Delete(reflect.New(list.Type().Elem()).Interface()).Error; err != nil {
+		return err
+	}
+	if list.Len() == 0 {
+		return nil
+	}
+	return tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(list.Addr().Interface()).Error
+}
+
+// changedFields — ключи, значения которых различаются в двух снимках
+func changedFields(a, b snapshotJSON) ([]string, map[string][2]json.RawMessage, error) {
+	am, bm := map[string]json.RawMessage{}, map[string]json.RawMessage{}
+	if a != nil {
+		if err := json.Unmarshal(a, &am); err != nil {
+			return nil, nil, err
+		}
+	}
+	if b != nil {
+		if err := json.Unmarshal(b, &bm); err != nil {
+			return nil, nil, err
+		}
+	}
+	values := map[string][2]json.RawMessage{}
+	for k, v := range am {
+		if !bytes.Equal(v, bm[k]) {
+			values[k] = [2]json.RawMessage{v, bm[k]}
+		}
+	}
+	for k, v := range bm {
+		if _, ok := am[k]; !ok {
+			values[k] = [2]json.RawMessage{nil, v}
+		}
+	}
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys, values, nil
+}
+
+// RevisionSummary — строка истории: без снимков, но со списком изменённых полей
+type RevisionSummary struct {
+	ID        int64     `json:"id"`
+	Action    string    `json:"action"`
+	Actor     string    `json:"actor"`
+	CreatedAt time.Time `json:"created_at"`
+	Fields    []string  `json:"fields"`
+}
+
+// GetHistory — GET /api/<type>/:id/history?limit=&full=: правки от новых к старым.
+// full=true добавляет снимки before/after
+func GetHistory(name string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := getID(c)
+		if !ok {
+			return
+		}
+		limit := defaultPageSize
+		if v := c.Query("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 || n > maxPageSize {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("limit must be between 1 and %d", maxPageSize)})
+				return
+			}
+			limit = n
+		}
+
+		var list []Revision
+		if err := DB.Where("entity_type = ? AND entity_id = ?", name, id).
+			Order("id DESC").Limit(limit).Find(&list).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if full, _ := strconv.ParseBool(c.Query("full")); full {
+			c.JSON(http.StatusOK, list)
+			return
+		}
+
+		out := make([]RevisionSummary, len(list))
+		for i, r := range list {
+			fields, _, err := changedFields(r.Before, r.After)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			out[i] = RevisionSummary{ID: r.ID, Action: r.Action, Actor: r.Actor, CreatedAt: r.CreatedAt, Fields: fields}
+		}
+		c.JSON(http.StatusOK, out)
+	}
+}
+
+// FieldChange — значение поля в двух состояниях записи
+type FieldChange struct {
+	Field string          `json:"field"`
+	From  json.RawMessage `json:"from"`
+	To    json.RawMessage `json:"to"`
+}
+
+// findRevision загружает ревизию rev записи name/id; при ошибке ответ уже записан
+func findRevision(c *gin.Context, name string, id int, param string) (*Revision, bool) {
+	rev, err := strconv.ParseInt(param, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid revision %q", param)})
+		return nil, false
+	}
+	var r Revision
+	if err := DB.Where("id = ? AND entity_type = ? AND entity_id = ?", rev, name, id).First(&r).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "revision not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return nil, false
+	}
+	return &r, true
+}
+
+// GetRevisionDiff — GET /api/<type>/:id/diff?from=&to=: поля, которые различаются
+// в состоянии после ревизии from и после ревизии to. Без to — что изменила сама ревизия from
+func GetRevisionDiff(name string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := getID(c)
+		if !ok {
+			return
+		}
+		if c.Query("from") == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from is required"})
+			return
+		}
+		from, ok := findRevision(c, name, id, c.Query("from"))
+		if !ok {
+			return
+		}
+		a, b := from.Before, from.After
+		result := gin.H{"from": from.ID}
+		if v := c.Query("to"); v != "" {
+			to, ok := findRevision(c, name, id, v)
+			if !ok {
+				return
+			}
+			a, b = from.After, to.After
+			result["to"] = to.ID
+		}
+
+		fields, values, err := changedFields(a, b)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		changes := make([]FieldChange, len(fields))
+		for i, f := range fields {
+			changes[i] = FieldChange{Field: f, From: nullJSON(values[f][0]), To: nullJSON(values[f][1])}
+		}
+		result["changes"] = changes
+		c.JSON(http.StatusOK, result)
+	}
+}
+
+func nullJSON(v json.RawMessage) json.RawMessage {
+	if v == nil {
+		return json.RawMessage("null")
+	}
+	return v
+}
+
+// RevertRevision — POST /api/<type>/:id/revert/:rev: вернуть запись в состояние после ревизии rev.
+// Откат сам записывается новой ревизией, поэтому его тоже можно откатить
+func RevertRevision(name string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := getID(c)
+		if !ok {
+			return
+		}
+		rev, ok := findRevision(c, name, id, c.Param("rev"))
+		if !ok {
+			return
+		}
+		if rev.After == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "revision deleted the record, restore it from trash instead"})
+			return
+		}
+
+		var versions []int
+		if err := DB.Table(entities[name].table).Where("id = ? AND deleted_at IS NULL", id).Pluck("version", &versions).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if len(versions) == 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "record is deleted, restore it from trash first"})
+			return
+		}
+		if !checkIfMatch(c, versionETag(versions[0]), currentItem(name, id)) {
+			return
+		}
+		if !checkSnapshotRef(c, name, rev.After) {
+			return
+		}
+
+		if err := withRevision(actorOf(c), name, &id, actionRevert, func(tx *gorm.DB) error {
+			return applySnapshot(tx, name, id, versions[0], rev.After)
+		}); err != nil {
+			saveFailed(c, err, currentItem(name, id))
+			return
+		}
+		obj, err := currentItem(name, id)()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.Header("ETag", itemETag(obj))
+		c.JSON(http.StatusOK, obj)
+	}
+}
+
+// checkSnapshotRef — родитель из снимка (категория, грамматика, правило) всё ещё существует
+func checkSnapshotRef(c *gin.Context, name string, data snapshotJSON) bool {
+	t := entities[name]
+	if t.parent == "" {
+		return true
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return false
+	}
+	var parentID int
+	if v, ok := raw[t.fk]; ok {
+		if err := json.Unmarshal(v, &parentID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return false
+		}
+	}
+	return checkRef(c, t.fk, entities[t.parent].table, parentID, !t.cascade)
+}
+
+// createRecorded вставляет новую запись obj и пишет её первую ревизию; id — поле ID в obj
+func createRecorded(actor, name string, obj any, id *int) error {
+	return withRevision(actor, name, id, actionCreate, func(tx *gorm.DB) error {
+		return tx.Create(obj).Error
+	})
+}
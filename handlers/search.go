@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"bd_back_for_translate_app/languages"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+// searchHeadline — параметры ts_headline для сниппетов с подсветкой
+const searchHeadline = "StartSel=<mark>, StopSel=</mark>, MaxWords=30, MinWords=10, MaxFragments=2"
+
+// searchTables — таблицы переводов с tsvector; перечислены для переиндексации
+var searchTables = []string{
+	"word_translations",
+	"text_translations",
+	"grammar_translations",
+	"grammar_rule_translations",
+}
+
+type SearchResult struct {
+	Type      string  `json:"type"`
+	ID        int     `json:"id"`
+	GrammarID *int    `json:"grammar_id,omitempty"`
+	Lang      string  `json:"lang"`
+	Title     string  `json:"title"`
+	Snippet   string  `json:"snippet"`
+	Rank      float64 `json:"rank"`
+}
+
+// searchSources — подзапросы по типам из ?types=; q — tsquery в конфигурации языка строки
+var searchSources = map[string][]string{
+	"words": {`
+		SELECT 'word' AS type, t.word_id AS id, NULL::int AS grammar_id, t.lang,
+		       t.word AS title,
+		       ts_headline(lang_ts_config(t.lang), t.word, q, '` + searchHeadline + `') AS snippet,
+		       ts_rank(t.search_vector, q) AS rank
+		FROM word_translations t
+		JOIN words o ON o.id = t.word_id AND o.deleted_at IS NULL,
+		LATERAL websearch_to_tsquery(lang_ts_config(t.lang), @q) q
+		WHERE t.search_vector @@ q AND (@lang = '' OR t.lang = @lang)
+		  AND (@editor OR ` + published("o") + `)`},
+	"texts": {`
+		SELECT 'text', t.text_id, NULL::int, t.lang,
+		       t.title,
+		       ts_headline(lang_ts_config(t.lang), t.title || ' ' || t.content, q, '` + searchHeadline + `'),
+		       ts_rank(t.search_vector, q)
+		FROM text_translations t
+		JOIN texts o ON o.id = t.text_id AND o.deleted_at IS NULL,
+		LATERAL websearch_to_tsquery(lang_ts_config(t.lang), @q) q
+		WHERE t.search_vector @@ q AND (@lang = '' OR t.lang = @lang)
+		  AND (@editor OR ` + published("o") + `)`},
+	"grammars": {`
+		SELECT 'grammar', t.grammar_id, t.grammar_id, t.lang,
+		       t.title,
+		       ts_headline(lang_ts_config(t.lang), t.title || ' ' || t.description, q, '` + searchHeadline + `'),
+		       ts_rank(t.search_vector, q)
+		FROM grammar_translations t
+		JOIN grammars o ON o.id = t.grammar_id AND o.deleted_at IS NULL,
+		LATERAL websearch_to_tsquery(lang_ts_config(t.lang), @q) q
+		WHERE t.search_vector @@ q AND (@lang = '' OR t.lang = @lang)
+		  AND (@editor OR ` + published("o") + `)`, `
+		SELECT 'grammar_rule', t.rule_id, r.grammar_id, t.lang,
+		       t.name,
+		       ts_headline(lang_ts_config(t.lang), t.name || ' ' || t.description, q, '` + searchHeadline + `'),
+		       ts_rank(t.search_vector, q)
+		FROM grammar_rule_translations t
+		JOIN grammar_rules r ON r.id = t.rule_id AND r.deleted_at IS NULL
+		JOIN grammars o ON o.id = r.grammar_id,
+		LATERAL websearch_to_tsquery(lang_ts_config(t.lang), @q) q
+		WHERE t.search_vector @@ q AND (@lang = '' OR t.lang = @lang)
+		  AND (@editor OR ` + published("o") + `)`},
+}
+
+// SyncSearchConfigs переносит конфигурации поиска из реестра языков в БД и
+// переиндексирует переводы на языках, у которых конфигурация изменилась
+func SyncSearchConfigs() error {
+	for _, l := range languages.List() {
+		var changed []string
+		if err := DB.Raw(`
+			INSERT INTO text_search_configs (lang, config) VALUES (?, ?::regconfig)
+			ON CONFLICT (lang) DO UPDATE SET config = EXCLUDED.config
+			WHERE text_search_configs.config IS DISTINCT FROM EXCLUDED.config
+			RETURNING lang`, l.Code, l.TextSearch).Scan(&changed).Error; err != nil {
+			return fmt.Errorf("language %s: %w", l.Code, err)
+		}
+		if len(changed) == 0 {
+			continue
+		}
+		for _, table := range searchTables {
+			// UPDATE запускает триггер, который пересчитывает search_vector
+			if err := DB.Exec("UPDATE "+table+" SET lang = lang WHERE lang = ?", l.Code).Error; err != nil {
+				return err
+			}
+		}
+		log.Printf("[search] %s reindexed with %s", l.Code, l.TextSearch)
+	}
+	return nil
+}
+
+// Search — GET /api/search?q=&lang=&types=words,texts,grammars&limit=.
+// Читатели находят только опубликованное
+func Search(c *gin.Context) {
+	q := strings.TrimSpace(c.Query("q"))
+	if q == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "q is required"})
+		return
+	}
+	lang := c.Query("lang")
+	if !validLanguage(c, lang) {
+		return
+	}
+
+	limit := defaultSearchLimit
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxSearchLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("limit must be between 1 and %d", maxSearchLimit)})
+			return
+		}
+		limit = n
+	}
+
+	types := []string{"words", "texts", "grammars"}
+	if v := c.Query("types"); v != "" {
+		types = strings.Split(v, ",")
+	}
+	var parts []string
+	seen := map[string]bool{}
+	for _, t := range types {
+		t = strings.TrimSpace(t)
+		sources, ok := searchSources[t]
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("unknown type %q", t)})
+			return
+		}
+		if !seen[t] {
+			seen[t] = true
+			parts = append(parts, sources...)
+		}
+	}
+
+	sql := strings.Join(parts, "\nUNION ALL\n") + "\nORDER BY rank DESC, type, id LIMIT @limit"
+	results := []SearchResult{}
+	if err := DB.Raw(sql, map[string]any{"q": q, "lang": lang, "limit": limit, "editor": isEditor(c)}).Scan(&results).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, results)
+}
@@ -8,6 +8,8 @@ import (
 	"os"
 	"os/exec"
 	"sync"
+
+	"bd_back_for_translate_app/languages"
 )
 
 type STTClient struct {
@@ -22,6 +24,7 @@ func NewSTTClient(pythonScriptPath string) (*STTClient, error) {
 	cmd.Env = append(
 		os.Environ(),
 		"PYTHONIOENCODING=utf-8",
+		"LANGUAGES_JSON="+languages.JSON(),
 	)
 
 	stdin, err := cmd.StdinPipe()
@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// syncTypes — сущности, которые отдаёт синхронизация
+var syncTypes = []string{"categories", "words", "texts", "grammars", "grammar_rules", "grammar_examples", "grammar_exceptions"}
+
+const (
+	defaultChangesRetention     = 90 * 24 * time.Hour
+	defaultChangesPruneInterval = time.Hour
+)
+
+var (
+	errBadToken    = errors.New("invalid since token")
+	errTokenTooOld = errors.New("token too old, do a full resync")
+)
+
+// SyncResponse — изменения с прошлого токена: актуальные записи и id удалённых по типам
+type SyncResponse struct {
+	Token   string           `json:"token"`
+	Full    bool             `json:"full"`
+	Updated map[string][]any `json:"updated"`
+	Deleted map[string][]int `json:"deleted"`
+}
+
+// GetSync — GET /api/sync?since=<token>. Без since отдаёт все записи (полная синхронизация),
+// с since — записи, изменённые после выдачи токена, и id удалённых (в том числе в корзину).
+// Записи в формате API без клипов. Одна запись может прийти повторно — клиент просто перезаписывает её.
+// Если часть журнала после токена уже вычищена, отвечает 410: клиенту нужна полная синхронизация
+func GetSync(c *gin.Context) {
+	since := c.Query("since")
+	if since != "" {
+		if _, err := strconv.ParseUint(since, 10, 64); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": errBadToken.Error()})
+			return
+		}
+	}
+
+	resp := SyncResponse{Full: since == "", Updated: map[string][]any{}, Deleted: map[string][]int{}}
+	// токен — xmin снимка: все транзакции до него уже видны в этом снимке,
+	// а те, что были ещё не закончены, попадут в следующую синхронизацию
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Raw("SELECT pg_snapshot_xmin(pg_current_snapshot())::text").Scan(&resp.Token).Error; err != nil {
+			return err
+		}
+
+		if since != "" {
+			var stale bool
+			if err := tx.Raw("SELECT EXISTS (SELECT 1 FROM changes_horizon WHERE txid >= CAST(? AS xid8))",
+				since).Scan(&stale).Error; err != nil {
+				return err
+			}
+			if stale {
+				return errTokenTooOld
+			}
+		}
+
+		changed := map[string][]int{}
+		if since == "" {
+			for _, name := range syncTypes {
+				var ids []int
+				if err := visibleTo(c, name, tx.Table(entities[name].table)).Where("deleted_at IS NULL").Order("id").Pluck("id", &ids).Error; err != nil {
+					return err
+				}
+				changed[name] = ids
+			}
+		} else {
+			var rows []struct {
+				EntityType string
+				EntityID   int
+			}
+			if err := tx.Raw("SELECT DISTINCT entity_type, entity_id FROM changes WHERE txid >= ?::xid8 ORDER BY entity_type, entity_id",
+				since).Scan(&rows).Error; err != nil {
+				return err
+			}
+			for _, r := range rows {
+				changed[r.EntityType] = append(changed[r.EntityType], r.EntityID)
+			}
+		}
+
+		for _, name := range syncTypes {
+			resp.Updated[name] = []any{}
+			resp.Deleted[name] = []int{}
+			ids := changed[name]
+			if len(ids) == 0 {
+				continue
+			}
+			items, err := entities[name].load(visibleTo(c, name, tx.Scopes(notDeleted)), ids)
+			if err != nil {
+				return err
+			}
+			for _, id := range ids {
+				if item, ok := items[id]; ok {
+					resp.Updated[name] = append(resp.Updated[name], item)
+				} else {
+					resp.Deleted[name] = append(resp.Deleted[name], id)
+				}
+			}
+		}
+		return nil
+	}, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if errors.Is(err, errTokenTooOld) {
+		c.JSON(http.StatusGone, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// PruneChanges удаляет из журнала изменения старше retention и сдвигает горизонт —
+// самую позднюю вычищенную транзакцию. Токены не новее горизонта больше не принимаются
+func PruneChanges(retention time.Duration) (int64, error) {
+	var pruned int64
+	err := DB.Raw(`WITH gone AS (
+    DELETE FROM changes WHERE changed_at < ? RETURNING txid
+), horizon AS (
+    UPDATE changes_horizon SET txid = greatest(txid, (SELECT txid FROM gone ORDER BY txid DESC LIMIT 1))
+    WHERE EXISTS (SELECT 1 FROM gone)
+)
+SELECT count(*) FROM gone`, time.Now().Add(-retention)).Scan(&pruned).Error
+	return pruned, err
+}
+
+// StartChangesPrune запускает фоновую очистку журнала синхронизации.
+// CHANGES_RETENTION — сколько хранить изменения (по умолчанию 2160h),
+// CHANGES_PRUNE_INTERVAL — как часто проверять (по умолчанию 1h)
+func StartChangesPrune() error {
+	retention, err := envDuration("CHANGES_RETENTION", defaultChangesRetention)
+	if err != nil {
+		return err
+	}
+	interval, err := envDuration("CHANGES_PRUNE_INTERVAL", defaultChangesPruneInterval)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		for {
+			if n, err := PruneChanges(retention); err != nil {
+				log.Printf("[sync] prune error: %v", err)
+			} else if n > 0 {
+				log.Printf("[sync] pruned %d change(s) older than %s", n, retention)
+			}
+			time.Sleep(interval)
+		}
+	}()
+	return nil
+}
@@ -2,39 +2,139 @@ package handlers
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // Text — модель текста с внешним ключом на Category
-// Ассоциация подтягивается через Preload в хендлерах
+// Переводы подтягиваются через Preload в хендлерах
 type Text struct {
-	ID              int    `gorm:"primaryKey;column:id"        json:"id"`
-	TitleRu         string `gorm:"column:title_ru"             json:"title_ru"`
-	TitleEn         string `gorm:"column:title_en"             json:"title_en"`
-	TitleDe         string `gorm:"column:title_de"             json:"title_de"`
-	ContentRu       string `gorm:"column:content_ru"           json:"content_ru"`
-	ContentEn       string `gorm:"column:content_en"           json:"content_en"`
-	ContentDe       string `gorm:"column:content_de"           json:"content_de"`
-	TranscriptionRu string `gorm:"column:transcription_ru"     json:"transcription_ru"`
-	TranscriptionEn string `gorm:"column:transcription_en"     json:"transcription_en"`
-	TranscriptionDe string `gorm:"column:transcription_de"     json:"transcription_de"`
-	AudioRu         []byte `gorm:"column:audio_ru"             json:"audio_ru"`
-	AudioEn         []byte `gorm:"column:audio_en"             json:"audio_en"`
-	AudioDe         []byte `gorm:"column:audio_de"             json:"audio_de"`
-	CategoryID      int    `gorm:"column:category_id"          json:"category_id"`
+	ID           int               `gorm:"primaryKey;column:id"        json:"id"`
+	CategoryID   nullID            `gorm:"column:category_id"          json:"category_id"`
+	Status       string            `gorm:"column:status;<-:create"     json:"status"`
+	PublishAt    *time.Time        `gorm:"column:publish_at;<-:create" json:"publish_at"`
+	DeletedAt    gorm.DeletedAt    `gorm:"column:deleted_at"           json:"-"`
+	Version      int               `gorm:"column:version;default:1"    json:"-"`
+	UpdatedAt    time.Time         `gorm:"column:updated_at"           json:"updated_at"`
+	Translations []TextTranslation `gorm:"foreignKey:TextID"           json:"-"`
 }
 
 func (Text) TableName() string { return "texts" }
 
+type TextTranslation struct {
+	TextID        int     `gorm:"primaryKey;column:text_id"`
+	Lang          string  `gorm:"primaryKey;column:lang"`
+	Title         string  `gorm:"column:title"`
+	Content       string  `gorm:"column:content"`
+	Transcription string  `gorm:"column:transcription"`
+	AudioID       *string `gorm:"column:audio_id"`
+	Audio         []byte  `gorm:"-"`
+}
+
+func (TextTranslation) TableName() string { return "text_translations" }
+
+func (t *TextTranslation) language() string                 { return t.Lang }
+func (t *TextTranslation) setLanguage(lang string)          { t.Lang = lang }
+func (t *TextTranslation) setOwner(id int)                  { t.TextID = id }
+func (t *TextTranslation) audioFields() (**string, *[]byte) { return &t.AudioID, &t.Audio }
+func (t *TextTranslation) jsonFields() map[string]any {
+	return map[string]any{
+		"title":         &t.Title,
+		"content":       &t.Content,
+		"transcription": &t.Transcription,
+		"audio":         &t.Audio,
+	}
+}
+
+type textJSON Text
+
+func (t Text) MarshalJSON() ([]byte, error) {
+	return marshalTranslated(textJSON(t), t.Translations, nil)
+}
+
+func (t *Text) UnmarshalJSON(data []byte) error {
+	return unmarshalTranslated(data, (*textJSON)(t), &t.Translations, nil)
+}
+
+var textList = &listSpec{
+	table: "texts",
+	sorts: map[string]sortField{
+		"category_id": {expr: "COALESCE(texts.category_id, 0)", kind: sortInt},
+		"status":      {expr: "texts.status", kind: sortText},
+	},
+	translated: []translatedSort{
+		{prefix: "title", table: "text_translations", fk: "text_id", column: "title"},
+	},
+	filters: map[string]listFilter{
+		"category_id": intFilter("COALESCE(texts.category_id, 0)"),
+		"status":      textFilter("texts.status"),
+		"language":    languageFilter("texts", "text_translations", "text_id", "content"),
+	},
+}
+
+var textFields = &fieldSpec{
+	table: "texts",
+	columns: map[string]string{
+		"category_id": "category_id",
+		"status":      "status",
+		"publish_at":  "publish_at",
+		"updated_at":  "updated_at",
+	},
+	trFK: "text_id",
+	translated: map[string]string{
+		"title":         "title",
+		"content":       "content",
+		"transcription": "transcription",
+		"audio":         "audio_id",
+	},
+	audio: "audio",
+}
+
 func GetTexts(c *gin.Context) {
+	p, ok := parseFields(c, textFields, false)
+	if !ok {
+		return
+	}
 	var list []Text
-	if err := DB.Find(&list).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if !findPage(c, textList, visibleTo(c, "texts", DB), p.apply, &list) {
+		return
+	}
+	if p.audio {
+		for i := range list {
+			loadAudio(list[i].Translations)
+		}
+	}
+	p.respond(c, http.StatusOK, list)
+}
+
+func GetText(c *gin.Context) {
+	id, ok := getID(c)
+	if !ok {
+		return
+	}
+	p, ok := parseFields(c, textFields, true)
+	if !ok {
+		return
+	}
+	var obj Text
+	if err := p.apply(visibleTo(c, "texts", DB)).First(&obj, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "text not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	if setETag(c, versionETag(obj.Version)) {
 		return
 	}
-	c.JSON(http.StatusOK, list)
+	if p.audio {
+		loadAudio(obj.Translations)
+	}
+	p.respond(c, http.StatusOK, obj)
 }
 
 func CreateText(c *gin.Context) {
@@ -42,12 +142,24 @@ func CreateText(c *gin.Context) {
 	if !bindJSON(c, &obj) {
 		return
 	}
-	obj.AudioRu, obj.AudioEn, obj.AudioDe = genAudioForText(&obj)
-	if err := DB.Create(&obj).Error; err != nil {
+	if !validateUnderWorkflow(c, obj, obj.Status, obj.PublishAt, statusDraft, nil) {
+		return
+	}
+	genAudioForText(&obj)
+	err := withRevision(actorOf(c), "texts", &obj.ID, actionCreate, func(tx *gorm.DB) error {
+		if err := storeTranslationAudio(tx, obj.Translations); err != nil {
+			return err
+		}
+		return tx.Create(&obj).Error
+	})
+	if err != nil {
+		releaseAudio(audioIDs(obj.Translations))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	DB.First(&obj, obj.ID)
+	DB.Preload("Translations").First(&obj, obj.ID)
+	loadAudio(obj.Translations)
+	c.Header("ETag", versionETag(obj.Version))
 	c.JSON(http.StatusCreated, obj)
 }
 
@@ -65,40 +177,57 @@ func UpdateText(c *gin.Context) {
 		}
 		return
 	}
+	version := obj.Version
+	if !checkIfMatch(c, versionETag(version), currentItem("texts", id)) {
+		return
+	}
 	var input Text
 	if !bindJSON(c, &input) {
 		return
 	}
+	if !validateUnderWorkflow(c, input, input.Status, input.PublishAt, obj.Status, obj.PublishAt) {
+		return
+	}
 	obj = Text{
-		ID:              id,
-		TitleRu:         input.TitleRu,
-		TitleEn:         input.TitleEn,
-		TitleDe:         input.TitleDe,
-		ContentRu:       input.ContentRu,
-		ContentEn:       input.ContentEn,
-		ContentDe:       input.ContentDe,
-		TranscriptionRu: input.TranscriptionRu,
-		TranscriptionEn: input.TranscriptionEn,
-		TranscriptionDe: input.TranscriptionDe,
-		CategoryID:      input.CategoryID,
-	}
-	obj.AudioRu, obj.AudioEn, obj.AudioDe = genAudioForText(&obj)
-	if err := DB.Save(&obj).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ID:           id,
+		Version:      version + 1,
+		CategoryID:   input.CategoryID,
+		Translations: input.Translations,
+	}
+	genAudioForText(&obj)
+	// клипы прежних переводов: после сохранения они освобождаются, если больше не нужны
+	var oldAudio []string
+	err := withRevision(actorOf(c), "texts", &id, actionUpdate, func(tx *gorm.DB) error {
+		if err := bumpVersion(tx, "texts", id, version); err != nil {
+			return err
+		}
+		if err := tx.Omit(clause.Associations).Save(&obj).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&TextTranslation{}).Where("text_id = ? AND audio_id IS NOT NULL", id).Pluck("audio_id", &oldAudio).Error; err != nil {
+			return err
+		}
+		// текст заменяется целиком: переводы, которых нет во входных данных, удаляются
+		if err := tx.Where("text_id = ?", id).Delete(&TextTranslation{}).Error; err != nil {
+			return err
+		}
+		if err := storeTranslationAudio(tx, obj.Translations); err != nil {
+			return err
+		}
+		return saveTranslations(tx, id, obj.Translations)
+	})
+	if err != nil {
+		releaseAudio(audioIDs(obj.Translations))
+		saveFailed(c, err, currentItem("texts", id))
 		return
 	}
-	DB.First(&obj, id)
+	releaseAudio(oldAudio)
+	DB.Preload("Translations").First(&obj, id)
+	loadAudio(obj.Translations)
+	c.Header("ETag", versionETag(obj.Version))
 	c.JSON(http.StatusOK, obj)
 }
 
 func DeleteText(c *gin.Context) {
-	id, ok := getID(c)
-	if !ok {
-		return
-	}
-	if err := DB.Delete(&Text{}, id).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-	c.Status(http.StatusNoContent)
+	moveToTrash(c, "texts")
 }
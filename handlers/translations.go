@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"encoding/json"
+
+	"bd_back_for_translate_app/languages"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// translation — строка таблицы *_translations: поля сущности на одном языке.
+// jsonFields отдаёт префикс JSON-ключа и указатель на поле: в API поле
+// выглядит как <префикс>_<код языка>, как и до нормализации схемы.
+type translation interface {
+	language() string
+	setLanguage(lang string)
+	setOwner(id int)
+	jsonFields() map[string]any
+}
+
+type translationPtr[T any] interface {
+	*T
+	translation
+}
+
+// marshalTranslated сериализует сущность в плоский JSON: поля base плюс
+// <поле>_<язык> для каждого языка из реестра (отсутствующий перевод — пустые значения).
+// aliases переименовывает ключи для обратной совместимости: старый ключ → канонический.
+func marshalTranslated[T any, P translationPtr[T]](base any, list []T, aliases map[string]string) ([]byte, error) {
+	data, err := json.Marshal(base)
+	if err != nil {
+		return nil, err
+	}
+	out := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, err
+	}
+
+	byLang := make(map[string]P, len(list))
+	for i := range list {
+		p := P(&list[i])
+		byLang[p.language()] = p
+	}
+
+	for _, lang := range languages.Codes() {
+		p, ok := byLang[lang]
+		if !ok {
+			p = P(new(T))
+		}
+		for name, ptr := range p.jsonFields() {
+			v, err := json.Marshal(ptr)
+			if err != nil {
+				return nil, err
+			}
+			out[name+"_"+lang] = v
+		}
+	}
+
+	for legacy, canonical := range aliases {
+		if v, ok := out[canonical]; ok {
+			out[legacy] = v
+			delete(out, canonical)
+		}
+	}
+	return json.Marshal(out)
+}
+
+// unmarshalTranslated разбирает плоский JSON в base и *list. Уже загруженные
+// переводы дополняются: меняются только поля, ключи которых есть в data.
+func unmarshalTranslated[T any, P translationPtr[T]](data []byte, base any, list *[]T, aliases map[string]string) error {
+	if err := json.Unmarshal(data, base); err != nil {
+		return err
+	}
+	raw := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	for legacy, canonical := range aliases {
+		if v, ok := raw[legacy]; ok {
+			raw[canonical] = v
+		}
+	}
+
+	for _, lang := range languages.Codes() {
+		idx := -1
+		for i := range *list {
+			if P(&(*list)[i]).language() == lang {
+				idx = i
+				break
+			}
+		}
+
+		var fresh T
+		p := P(&fresh)
+		if idx >= 0 {
+			p = P(&(*list)[idx])
+		}
+
+		found := false
+		for name, ptr := range p.jsonFields() {
+			v, ok := raw[name+"_"+lang]
+			if !ok {
+				continue
+			}
+			if err := json.Unmarshal(v, ptr); err != nil {
+				return err
+			}
+			found = true
+		}
+		if found && idx < 0 {
+			p.setLanguage(lang)
+			*list = append(*list, fresh)
+		}
+	}
+	return nil
+}
+
+// saveTranslations привязывает переводы к сущности ownerID и вставляет или
+// обновляет их целиком (upsert по первичному ключу)
+func saveTranslations[T any, P translationPtr[T]](tx *gorm.DB, ownerID int, list []T) error {
+	if len(list) == 0 {
+		return nil
+	}
+	for i := range list {
+		P(&list[i]).setOwner(ownerID)
+	}
+	return tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(&list).Error
+}
+
+// saveTranslated сохраняет сущность без ассоциаций и её переводы в одной транзакции с ревизией.
+// version — версия, которую видел клиент; у obj поле Version уже должно быть version+1
+func saveTranslated[T any, P translationPtr[T]](actor string, obj any, name string, id, version int, list []T) error {
+	return withRevision(actor, name, &id, actionUpdate, func(tx *gorm.DB) error {
+		if err := bumpVersion(tx, name, id, version); err != nil {
+			return err
+		}
+		if err := tx.Omit(clause.Associations).Save(obj).Error; err != nil {
+			return err
+		}
+		return saveTranslations[T, P](tx, id, list)
+	})
+}
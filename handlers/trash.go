@@ -0,0 +1,337 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultTrashRetention     = 30 * 24 * time.Hour
+	defaultTrashPurgeInterval = time.Hour
+)
+
+// trashOrder — порядок типов в ответе; очистка идёт в обратном порядке, от дочерних к родителям
+var trashOrder = []string{"categories", "words", "texts", "grammars", "grammar_rules", "grammar_examples", "grammar_exceptions"}
+
+var (
+	errNotInTrash    = errors.New("item not in trash")
+	errParentInTrash = errors.New("parent is in trash")
+)
+
+// TrashItem — запись корзины; Item — сущность в обычном JSON-представлении
+type TrashItem struct {
+	Type      string    `json:"type"`
+	ID        int       `json:"id"`
+	DeletedAt time.Time `json:"deleted_at"`
+	Item      any       `json:"item"`
+}
+
+// trashRows помечает строки удалёнными вместе с живыми дочерними записями.
+// У всех строк одна метка времени: по ней восстановление находит то, что удалялось вместе
+func trashRows(tx *gorm.DB, name string, ids []int, at time.Time) error {
+	if err := tx.Table(entities[name].table).
+		Where("id IN ? AND deleted_at IS NULL", ids).
+		Updates(map[string]any{"deleted_at": at, "version": gorm.Expr("version + 1")}).Error; err != nil {
+		return err
+	}
+	for child, t := range entities {
+		if t.parent != name || !t.cascade {
+			continue
+		}
+		var childIDs []int
+		if err := tx.Table(t.table).Where(t.fk+" IN ? AND deleted_at IS NULL", ids).Pluck("id", &childIDs).Error; err != nil {
+			return err
+		}
+		if len(childIDs) > 0 {
+			if err := trashRows(tx, child, childIDs, at); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// restoreRows возвращает строки и дочерние записи, удалённые в тот же момент at
+func restoreRows(tx *gorm.DB, name string, ids []int, at time.Time) error {
+	if err := tx.Table(entities[name].table).
+		Where("id IN ? AND deleted_at = ?", ids, at).
+		Updates(map[string]any{"deleted_at": nil, "version": gorm.Expr("version + 1")}).Error; err != nil {
+		return err
+	}
+	for child, t := range entities {
+		if t.parent != name || !t.cascade {
+			continue
+		}
+		var childIDs []int
+		if err := tx.Table(t.table).Where(t.fk+" IN ? AND deleted_at = ?", ids, at).Pluck("id", &childIDs).Error; err != nil {
+			return err
+		}
+		if len(childIDs) > 0 {
+			if err := restoreRows(tx, child, childIDs, at); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// moveToTrash — общий обработчик DELETE: запись уходит в корзину вместе с дочерними.
+// С If-Match удаляется только та версия, которую видел клиент
+func moveToTrash(c *gin.Context, name string) {
+	id, ok := getID(c)
+	if !ok {
+		return
+	}
+	var versions []int
+	if err := DB.Table(entities[name].table).Where("id = ? AND deleted_at IS NULL", id).Pluck("version", &versions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(versions) == 0 {
+		c.Status(http.StatusNoContent)
+		return
+	}
+	if !checkIfMatch(c, versionETag(versions[0]), currentItem(name, id)) {
+		return
+	}
+	checked := c.GetHeader("If-Match") != ""
+
+	at := time.Now().UTC().Truncate(time.Microsecond)
+	if err := withRevision(actorOf(c), name, &id, actionDelete, func(tx *gorm.DB) error {
+		if checked {
+			if err := lockVersion(tx, name, id, versions[0]); err != nil {
+				return err
+			}
+		}
+		return trashRows(tx, name, []int{id}, at)
+	}); err != nil {
+		saveFailed(c, err, currentItem(name, id))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// GetTrash — GET /api/trash?type=words,texts&limit=: удалённое, от свежего к старому.
+// Записи, удалённые вместе с родителем, отдельно не показываются
+func GetTrash(c *gin.Context) {
+	limit := defaultPageSize
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxPageSize {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("limit must be between 1 and %d", maxPageSize)})
+			return
+		}
+		limit = n
+	}
+
+	types := trashOrder
+	if v := c.Query("type"); v != "" {
+		types = strings.Split(v, ",")
+	}
+	var parts []string
+	seen := map[string]bool{}
+	for _, name := range types {
+		name = strings.TrimSpace(name)
+		t, ok := entities[name]
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("unknown type %q", name)})
+			return
+		}
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		part := fmt.Sprintf("SELECT '%s' AS type, t.id, t.deleted_at FROM %s t WHERE t.deleted_at IS NOT NULL", name, t.table)
+		if t.cascade {
+			part += fmt.Sprintf(" AND NOT EXISTS (SELECT 1 FROM %s p WHERE p.id = t.%s AND p.deleted_at = t.deleted_at)",
+				entities[t.parent].table, t.fk)
+		}
+		parts = append(parts, part)
+	}
+
+	var rows []struct {
+		Type      string
+		ID        int
+		DeletedAt time.Time
+	}
+	sql := strings.Join(parts, "\nUNION ALL\n") + "\nORDER BY deleted_at DESC, type, id LIMIT ?"
+	if err := DB.Raw(sql, limit).Scan(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ids := map[string][]int{}
+	for _, r := range rows {
+		ids[r.Type] = append(ids[r.Type], r.ID)
+	}
+	items := map[string]map[int]any{}
+	for name, list := range ids {
+		loaded, err := entities[name].load(DB, list)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		items[name] = loaded
+	}
+
+	result := make([]TrashItem, 0, len(rows))
+	for _, r := range rows {
+		// строку могла вычистить очистка между двумя запросами
+		if item, ok := items[r.Type][r.ID]; ok {
+			result = append(result, TrashItem{Type: r.Type, ID: r.ID, DeletedAt: r.DeletedAt, Item: item})
+		}
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// RestoreTrash — POST /api/trash/:type/:id/restore
+func RestoreTrash(c *gin.Context) {
+	name := c.Param("type")
+	t, ok := entities[name]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("unknown type %q", name)})
+		return
+	}
+	id, ok := getID(c)
+	if !ok {
+		return
+	}
+
+	err := withRevision(actorOf(c), name, &id, actionRestore, func(tx *gorm.DB) error {
+		var at []time.Time
+		if err := tx.Table(t.table).Where("id = ? AND deleted_at IS NOT NULL", id).Pluck("deleted_at", &at).Error; err != nil {
+			return err
+		}
+		if len(at) == 0 {
+			return errNotInTrash
+		}
+		if t.parent != "" {
+			var n int64
+			if err := tx.Table(entities[t.parent].table).
+				Where(fmt.Sprintf("id = (SELECT %s FROM %s WHERE id = ?) AND deleted_at IS NOT NULL", t.fk, t.table), id).
+				Count(&n).Error; err != nil {
+				return err
+			}
+			if n > 0 {
+				return errParentInTrash
+			}
+		}
+		return restoreRows(tx, name, []int{id}, at[0])
+	})
+	switch {
+	case errors.Is(err, errNotInTrash):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, errParentInTrash):
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("%s refers to %s in trash, restore it first", t.fk, t.parent)})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	items, err := t.load(DB, []int{id})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, items[id])
+}
+
+// PurgeTrash окончательно удаляет записи, пролежавшие в корзине дольше retention, вместе
+// с прогрессом учеников по ним и освобождает клипы, на которые больше никто не ссылается
+func PurgeTrash(retention time.Duration) (int64, error) {
+	cutoff := time.Now().Add(-retention)
+	var (
+		purged   int64
+		audioIDs []string
+	)
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		for i := len(trashOrder) - 1; i >= 0; i-- {
+			t := entities[trashOrder[i]]
+			if t.audioTable != "" {
+				var ids []string
+				if err := tx.Table(t.audioTable).
+					Where(t.trFK+" IN (SELECT id FROM "+t.table+" WHERE deleted_at < ?) AND audio_id IS NOT NULL", cutoff).
+					Pluck("audio_id", &ids).Error; err != nil {
+					return err
+				}
+				audioIDs = append(audioIDs, ids...)
+			}
+			// переводы и дочерние записи грамматики удаляются каскадно по внешним ключам
+			sql := "DELETE FROM " + t.table + " WHERE deleted_at < ?"
+			if t.purgeGuard != "" {
+				sql += " AND " + t.purgeGuard
+			}
+			res := tx.Exec(sql, cutoff)
+			if res.Error != nil {
+				return res.Error
+			}
+			purged += res.RowsAffected
+		}
+		if purged == 0 {
+			return nil
+		}
+		// у прогресса нет внешнего ключа на запись (entity разные), поэтому строки удалённых
+		// записей — и прямо из корзины, и ушедших каскадом с грамматикой — чистятся здесь
+		for _, name := range progressEntities {
+			if err := tx.Exec("DELETE FROM progress p WHERE p.entity = ? AND NOT EXISTS (SELECT 1 FROM "+
+				name+" e WHERE e.id = p.item_id)", name).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	releaseAudio(audioIDs)
+	return purged, nil
+}
+
+// StartTrashPurge запускает фоновую очистку корзины.
+// TRASH_RETENTION — сколько хранить удалённое (по умолчанию 720h),
+// TRASH_PURGE_INTERVAL — как часто проверять (по умолчанию 1h)
+func StartTrashPurge() error {
+	retention, err := envDuration("TRASH_RETENTION", defaultTrashRetention)
+	if err != nil {
+		return err
+	}
+	interval, err := envDuration("TRASH_PURGE_INTERVAL", defaultTrashPurgeInterval)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		for {
+			if n, err := PurgeTrash(retention); err != nil {
+				log.Printf("[trash] purge error: %v", err)
+			} else if n > 0 {
+				log.Printf("[trash] purged %d item(s) older than %s", n, retention)
+			}
+			time.Sleep(interval)
+		}
+	}()
+	return nil
+}
+
+func envDuration(name string, def time.Duration) (time.Duration, error) {
+	v := os.Getenv(name)
+	if v == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("%s must be a positive duration, got %q", name, v)
+	}
+	return d, nil
+}
@@ -11,6 +11,8 @@ import (
 	"os"
 	"os/exec"
 	"sync"
+
+	"bd_back_for_translate_app/languages"
 )
 
 var TtsClient *TTSClient
@@ -27,7 +29,7 @@ func NewTTSClient(pyScript string) (*TTSClient, error) {
 	log.Printf("[TTS] launching daemon: %s", pyScript)
 
 	cmd := exec.Command("python", pyScript)
-	cmd.Env = append(os.Environ(), "PYTHONIOENCODING=utf-8")
+	cmd.Env = append(os.Environ(), "PYTHONIOENCODING=utf-8", "LANGUAGES_JSON="+languages.JSON())
 
 	in, err := cmd.StdinPipe()
 	if err != nil {
@@ -54,6 +56,10 @@ func NewTTSClient(pyScript string) (*TTSClient, error) {
 
 /* ---------- synthesize with log ---------- */
 func (c *TTSClient) Synthesize(ipa, lang string) ([]byte, error) {
+	if !languages.Supported(lang) {
+		return nil, fmt.Errorf("unsupported language %q", lang)
+	}
+
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
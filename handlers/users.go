@@ -0,0 +1,377 @@
+package handlers
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"bd_back_for_translate_app/auth"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const maxEmailLen = 254
+
+var (
+	errEmailTaken  = errors.New("email already registered")
+	errTokenReused = errors.New("refresh token already used")
+)
+
+// User — учётная запись; роль определяет, что можно делать с содержимым
+type User struct {
+	ID           int       `gorm:"primaryKey;column:id" json:"id"`
+	Email        string    `gorm:"column:email"         json:"email"`
+	PasswordHash string    `gorm:"column:password_hash" json:"-"`
+	Role         string    `gorm:"column:role"          json:"role"`
+	CreatedAt    time.Time `gorm:"column:created_at"    json:"created_at"`
+}
+
+func (User) TableName() string { return "users" }
+
+// Credentials — тело регистрации и входа
+type Credentials struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// TokenResponse — пара токенов; expires_in — срок жизни access-токена в секундах
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+	User         User   `json:"user"`
+}
+
+type refreshInput struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func (in Credentials) validate(v *validator) {
+	switch addr, err := mail.ParseAddress(in.Email); {
+	case in.Email == "":
+		v.add("email", codeRequired, "is required")
+	case err != nil || addr.Address != in.Email:
+		v.add("email", codeInvalid, "is not a valid email address")
+	default:
+		v.maxLen("email", in.Email, maxEmailLen)
+	}
+	switch {
+	case in.Password == "":
+		v.add("password", codeRequired, "is required")
+	case len(in.Password) < auth.MinPasswordLen:
+		v.add("password", codeTooShort, "must be at least %d bytes", auth.MinPasswordLen)
+	case len(in.Password) > auth.MaxPasswordLen:
+		v.add("password", codeTooLong, "must be at most %d bytes", auth.MaxPasswordLen)
+	}
+}
+
+// createUser заводит пользователя; занятый email — errEmailTaken
+func createUser(email, password, role string) (User, error) {
+	hash, err := auth.HashPassword(password)
+	if err != nil {
+		return User{}, err
+	}
+	var users []User
+	if err := DB.Raw(`INSERT INTO users (email, password_hash, role) VALUES (?, ?, ?)
+		ON CONFLICT ((lower(email))) DO NOTHING RETURNING *`, email, hash, role).Scan(&users).Error; err != nil {
+		return User{}, err
+	}
+	if len(users) == 0 {
+		return User{}, errEmailTaken
+	}
+	return users[0], nil
+}
+
+// Register — POST /api/auth/register: новый пользователь с ролью learner и сразу пара токенов
+func Register(c *gin.Context) {
+	var in Credentials
+	if !bindJSON(c, &in) {
+		return
+	}
+	in.Email = normalizeEmail(in.Email)
+	if !validate(c, in) {
+		return
+	}
+	user, err := createUser(in.Email, in.Password, auth.RoleLearner)
+	if errors.Is(err, errEmailTaken) {
+		v := newValidator()
+		v.add("email", codeDuplicate, "is already registered")
+		v.respond(c)
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	issueTokens(c, DB, user, http.StatusCreated)
+}
+
+// Login — POST /api/auth/login
+func Login(c *gin.Context) {
+	var in Credentials
+	if !bindJSON(c, &in) {
+		return
+	}
+	var user User
+	err := DB.Where("lower(email) = ?", normalizeEmail(in.Email)).First(&user).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !auth.CheckPassword(user.PasswordHash, in.Password) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid email or password"})
+		return
+	}
+	// заодно чистим истёкшие refresh-токены пользователя
+	if err := DB.Where("user_id = ? AND expires_at < now()", user.ID).Delete(&refreshToken{}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	issueTokens(c, DB, user, http.StatusOK)
+}
+
+// refreshToken — выданный refresh-токен; по id (jti) он отзывается при обновлении и выходе
+type refreshToken struct {
+	ID        string     `gorm:"primaryKey;column:id"`
+	UserID    int        `gorm:"column:user_id"`
+	ExpiresAt time.Time  `gorm:"column:expires_at"`
+	RevokedAt *time.Time `gorm:"column:revoked_at"`
+}
+
+func (refreshToken) TableName() string { return "refresh_tokens" }
+
+// issueTokens выдаёт пару токенов; refresh-токен запоминается в БД через db
+func issueTokens(c *gin.Context, db *gorm.DB, user User, status int) {
+	resp, err := newTokens(db, user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(status, resp)
+}
+
+func newTokens(db *gorm.DB, user User) (TokenResponse, error) {
+	sub := strconv.Itoa(user.ID)
+	access, _, err := auth.Issue(auth.Claims{Subject: sub, Email: user.Email, Role: user.Role, Type: auth.AccessToken}, auth.AccessTTL)
+	if err != nil {
+		return TokenResponse{}, err
+	}
+	refresh, claims, err := auth.Issue(auth.Claims{Subject: sub, Type: auth.RefreshToken}, auth.RefreshTTL)
+	if err != nil {
+		return TokenResponse{}, err
+	}
+	rt := refreshToken{ID: claims.ID, UserID: user.ID, ExpiresAt: time.Unix(claims.ExpiresAt, 0)}
+	if err := db.Create(&rt).Error; err != nil {
+		return TokenResponse{}, err
+	}
+	return TokenResponse{
+		AccessToken:  access,
+		RefreshToken: refresh,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(auth.AccessTTL.Seconds()),
+		User:         user,
+	}, nil
+}
+
+// RefreshTokens — POST /api/auth/refresh: обмен refresh-токена на новую пару.
+// Роль берётся из БД, поэтому её смена вступает в силу с ближайшим обновлением.
+// Повторное предъявление уже использованного токена отзывает все токены пользователя
+func RefreshTokens(c *gin.Context) {
+	var in refreshInput
+	if !bindJSON(c, &in) {
+		return
+	}
+	claims, err := auth.Verify(in.RefreshToken, auth.RefreshToken)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	var resp TokenResponse
+	err = DB.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&refreshToken{}).
+			Where("id = ? AND revoked_at IS NULL AND expires_at > now()", claims.ID).
+			Update("revoked_at", gorm.Expr("now()"))
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errTokenReused
+		}
+		var user User
+		if err := tx.First(&user, claims.UserID()).Error; err != nil {
+			return err
+		}
+		tokens, err := newTokens(tx, user)
+		resp = tokens
+		return err
+	})
+	switch {
+	case errors.Is(err, errTokenReused):
+		if err := DB.Model(&refreshToken{}).Where("user_id = ? AND revoked_at IS NULL", claims.UserID()).
+			Update("revoked_at", gorm.Expr("now()")).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// Logout — POST /api/auth/logout: отзыв refresh-токена. Access-токен доживает свой короткий срок
+func Logout(c *gin.Context) {
+	var in refreshInput
+	if !bindJSON(c, &in) {
+		return
+	}
+	claims, err := auth.Verify(in.RefreshToken, auth.RefreshToken)
+	if err != nil && !errors.Is(err, auth.ErrExpiredToken) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	if claims != nil {
+		if err := DB.Model(&refreshToken{}).Where("id = ? AND revoked_at IS NULL", claims.ID).
+			Update("revoked_at", gorm.Expr("now()")).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// GetMe — GET /api/auth/me
+func GetMe(c *gin.Context) {
+	var user User
+	if err := DB.First(&user, currentUser(c).UserID()).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+// GetUsers — GET /api/users (только администраторы)
+func GetUsers(c *gin.Context) {
+	users := []User{}
+	if err := DB.Order("id").Find(&users).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, users)
+}
+
+// UpdateUserRole — PUT /api/users/:id/role {"role": "editor"}. Выданные access-токены
+// сохраняют старую роль до истечения; refresh-токены отзываются, новая роль — со следующего входа
+func UpdateUserRole(c *gin.Context) {
+	id, ok := getID(c)
+	if !ok {
+		return
+	}
+	var in struct {
+		Role string `json:"role"`
+	}
+	if !bindJSON(c, &in) {
+		return
+	}
+	v := newValidator()
+	v.oneOf("role", in.Role, auth.Roles, false)
+	if !v.respond(c) {
+		return
+	}
+
+	var user User
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&user, id).Error; err != nil {
+			return err
+		}
+		return setUserRole(tx, &user, in.Role)
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+// setUserRole меняет роль пользователя и отзывает его refresh-токены,
+// чтобы новая роль действовала со следующего входа
+func setUserRole(tx *gorm.DB, user *User, role string) error {
+	if err := tx.Model(user).Update("role", role).Error; err != nil {
+		return err
+	}
+	return tx.Model(&refreshToken{}).Where("user_id = ? AND revoked_at IS NULL", user.ID).
+		Update("revoked_at", gorm.Expr("now()")).Error
+}
+
+// RunUserCommand — `user add <email> <role>` и `user role <email> <role>` из командной строки,
+// например чтобы завести первого администратора. Пароль читается из stdin
+func RunUserCommand(args []string) error {
+	if len(args) != 3 || (args[0] != "add" && args[0] != "role") {
+		return fmt.Errorf("usage: user add <email> <role> | user role <email> <role>")
+	}
+	email, role := normalizeEmail(args[1]), args[2]
+	if !slices.Contains(auth.Roles, role) {
+		return fmt.Errorf("role must be one of %v", auth.Roles)
+	}
+
+	if args[0] == "role" {
+		err := DB.Transaction(func(tx *gorm.DB) error {
+			var user User
+			if err := tx.Where("lower(email) = ?", email).First(&user).Error; err != nil {
+				return err
+			}
+			return setUserRole(tx, &user, role)
+		})
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("user %s not found", email)
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s is now %s\n", email, role)
+		return nil
+	}
+
+	fmt.Fprint(os.Stderr, "password: ")
+	password, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && password == "" {
+		return fmt.Errorf("read password: %w", err)
+	}
+	in := Credentials{Email: email, Password: strings.TrimRight(password, "\r\n")}
+	v := newValidator()
+	in.validate(v)
+	if v.failed() {
+		return fmt.Errorf("%s: %s", v.errs[0].Field, v.errs[0].Message)
+	}
+	user, err := createUser(in.Email, in.Password, role)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("created user %d %s (%s)\n", user.ID, user.Email, user.Role)
+	return nil
+}
@@ -1,10 +1,14 @@
 package handlers
 
 import (
+	"database/sql/driver"
+	"fmt"
 	"log"
 	"net/http"
 	"strconv"
 
+	"bd_back_for_translate_app/languages"
+
 	"github.com/gin-gonic/gin"
 )
 
@@ -25,74 +29,73 @@ func bindJSON(c *gin.Context, obj interface{}) bool {
 	return true
 }
 
-func genAudioForWord(w *Word) (ru, en, de []byte) {
-	if TtsClient == nil {
-		return nil, nil, nil
+// validLanguage проверяет, что код языка есть в реестре; пустой код допустим
+func validLanguage(c *gin.Context, code string) bool {
+	if code != "" && !languages.Supported(code) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("unsupported language %q", code)})
+		return false
 	}
+	return true
+}
 
-	type rec struct {
-		text string
-		lang string
+// synthesize озвучивает text на языке lang; ошибки TTS только логируются
+func synthesize(id int, lang, text string) []byte {
+	if TtsClient == nil || text == "" || !languages.Supported(lang) {
+		return nil
 	}
-	langs := []rec{
-		{w.WordRu, "ru"},
-		{w.WordEn, "en"},
-		{w.WordDe, "de"},
+	wav, err := TtsClient.Synthesize(text, lang)
+	if err != nil {
+		log.Printf("[TTS] id=%d %s error: %v", id, lang, err)
+		return nil
 	}
+	return wav
+}
 
-	for i, r := range langs {
-		if r.text == "" {
-			continue
-		}
-		wav, err := TtsClient.Synthesize(r.text, r.lang)
-		if err != nil {
-			log.Printf("[TTS] id=%d %s error: %v", w.ID, r.lang, err)
-			continue
-		}
-		switch i {
-		case 0:
-			ru = wav
-		case 1:
-			en = wav
-		case 2:
-			de = wav
-		}
+// genAudioForWord синтезирует клипы переводов в поле Audio; в хранилище их кладёт
+// storeTranslationAudio в транзакции сохранения
+func genAudioForWord(w *Word) {
+	for i := range w.Translations {
+		t := &w.Translations[i]
+		t.Audio, t.AudioID = synthesize(w.ID, t.Lang, t.Word), nil
 	}
-	return ru, en, de
 }
 
-func genAudioForText(t *Text) (ru, en, de []byte) {
-	if TtsClient == nil {
-		return nil, nil, nil
+// genAudioForText — то же для текстов
+func genAudioForText(t *Text) {
+	for i := range t.Translations {
+		tr := &t.Translations[i]
+		tr.Audio, tr.AudioID = synthesize(t.ID, tr.Lang, tr.Content), nil
 	}
+}
 
-	type rec struct {
-		text string
-		lang string
-	}
-	langs := []rec{
-		{t.ContentRu, "ru"},
-		{t.ContentEn, "en"},
-		{t.ContentDe, "de"},
+// nullID — необязательная ссылка на другую сущность: в API это 0, в БД — NULL
+type nullID int
+
+func (n nullID) Value() (driver.Value, error) {
+	if n == 0 {
+		return nil, nil
 	}
+	return int64(n), nil
+}
 
-	for i, r := range langs {
-		if r.text == "" {
-			continue
-		}
-		wav, err := TtsClient.Synthesize(r.text, r.lang)
-		if err != nil {
-			log.Printf("[TTS] id=%d %s error: %v", t.ID, r.lang, err)
-			continue
-		}
-		switch i {
-		case 0:
-			ru = wav
-		case 1:
-			en = wav
-		case 2:
-			de = wav
-		}
+func (n *nullID) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*n = 0
+	case int64:
+		*n = nullID(v)
+	case int32:
+		*n = nullID(v)
+	default:
+		return fmt.Errorf("nullID: unsupported type %T", src)
 	}
-	return ru, en, de
+	return nil
+}
+
+// checkRef проверяет, что field ссылается на существующую и не удалённую строку table.
+// Нулевая ссылка допустима, если optional. При ошибке ответ уже записан
+func checkRef(c *gin.Context, field, table string, id int, optional bool) bool {
+	v := newValidator()
+	v.ref(field, table, id, optional)
+	return v.respond(c)
 }
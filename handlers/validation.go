@@ -0,0 +1,304 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"unicode/utf8"
+
+	"bd_back_for_translate_app/languages"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FieldError — ошибка одного поля ввода; field — JSON-ключ, как его отправил клиент
+// (для вложенных документов с путём: rules[0].rule_name_ru)
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Коды ошибок полей: по ним админка выбирает текст подсказки
+const (
+	codeRequired  = "required"
+	codeTooLong   = "too_long"
+	codeTooShort  = "too_short"
+	codeInvalid   = "invalid"
+	codeNotFound  = "not_found"
+	codeMismatch  = "mismatch"
+	codeDuplicate = "duplicate"
+)
+
+// Ограничения длины полей, в символах
+const (
+	maxShortText = 200
+	maxTitle     = 300
+	maxLongText  = 20000
+)
+
+// Допустимые значения перечислений
+var categoryEntities = []string{"word", "text"}
+
+// validatable — модель со своими правилами проверки
+type validatable interface {
+	validate(v *validator)
+}
+
+// validator собирает ошибки полей; path — префикс полей вложенного элемента.
+// Ошибка БД при проверке ссылок запоминается в err и прерывает дальнейшие запросы
+type validator struct {
+	*validation
+	path string
+}
+
+type validation struct {
+	errs []FieldError
+	err  error
+}
+
+func newValidator() *validator {
+	return &validator{validation: &validation{errs: []FieldError{}}}
+}
+
+// at — проверка вложенного элемента: ошибки пишутся в тот же список с префиксом path
+func (v *validator) at(path string) *validator {
+	if v.path != "" {
+		path = v.path + "." + path
+	}
+	return &validator{validation: v.validation, path: path}
+}
+
+func (v *validator) add(field, code, format string, args ...any) {
+	if v.path != "" {
+		field = v.path + "." + field
+	}
+	v.errs = append(v.errs, FieldError{Field: field, Code: code, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) failed() bool {
+	return len(v.errs) > 0
+}
+
+func (v *validator) maxLen(field, value string, n int) {
+	if utf8.RuneCountInString(value) > n {
+		v.add(field, codeTooLong, "must be at most %d characters", n)
+	}
+}
+
+func (v *validator) oneOf(field, value string, allowed []string, optional bool) {
+	if value == "" {
+		if !optional {
+			v.add(field, codeRequired, "is required")
+		}
+		return
+	}
+	for _, a := range allowed {
+		if value == a {
+			return
+		}
+	}
+	v.add(field, codeInvalid, "must be one of %v", allowed)
+}
+
+func (v *validator) language(field, code string) {
+	if code != "" && !languages.Supported(code) {
+		v.add(field, codeInvalid, "unsupported language %q", code)
+	}
+}
+
+// ref проверяет, что id ссылается на живую строку table; нулевая ссылка допустима, если optional
+func (v *validator) ref(field, table string, id int, optional bool) {
+	if id == 0 {
+		if !optional {
+			v.add(field, codeRequired, "is required")
+		}
+		return
+	}
+	if v.err != nil {
+		return
+	}
+	var n int64
+	if v.err = DB.Table(table).Where("id = ? AND deleted_at IS NULL", id).Count(&n).Error; v.err != nil {
+		return
+	}
+	if n == 0 {
+		v.add(field, codeNotFound, "%d does not exist", id)
+	}
+}
+
+// categoryRef — ссылка на категорию, которая существует и предназначена для entity
+func (v *validator) categoryRef(field string, id int, entity string) {
+	if id == 0 || v.err != nil {
+		return
+	}
+	var list []string
+	if v.err = DB.Table("categories").Where("id = ? AND deleted_at IS NULL", id).Pluck("entity", &list).Error; v.err != nil {
+		return
+	}
+	switch {
+	case len(list) == 0:
+		v.add(field, codeNotFound, "%d does not exist", id)
+	case list[0] != entity:
+		v.add(field, codeMismatch, "category %d is for %q, expected %q", id, list[0], entity)
+	}
+}
+
+// categoryUsage — у категории id не должно оставаться записей другой сущности, чем entity
+func (v *validator) categoryUsage(id int, entity string) {
+	for _, t := range []struct{ entity, table string }{{"word", "words"}, {"text", "texts"}} {
+		if t.entity == entity || v.err != nil {
+			continue
+		}
+		var n int64
+		if v.err = DB.Table(t.table).Where("category_id = ? AND deleted_at IS NULL", id).Count(&n).Error; v.err != nil {
+			return
+		}
+		if n > 0 {
+			v.add("entity", codeMismatch, "category still has %d %s", n, t.table)
+		}
+	}
+}
+
+// translatedField — правило для поля перевода: префикс JSON-ключа, значение, длина и обязательность
+type translatedField[P any] struct {
+	prefix   string
+	value    func(P) string
+	max      int
+	required bool
+}
+
+// checkTranslated проверяет переводы: обязательные поля на обязательных языках
+// и хотя бы на одном языке, если обязательных языков нет; длину — на всех языках
+func checkTranslated[T any, P translationPtr[T]](v *validator, list []T, fields ...translatedField[P]) {
+	byLang := make(map[string]P, len(list))
+	for i := range list {
+		p := P(&list[i])
+		byLang[p.language()] = p
+		for _, f := range fields {
+			v.maxLen(f.prefix+"_"+p.language(), f.value(p), f.max)
+		}
+	}
+	required := languages.Required()
+	for _, f := range fields {
+		if !f.required {
+			continue
+		}
+		present := false
+		for _, p := range byLang {
+			if f.value(p) != "" {
+				present = true
+			}
+		}
+		if len(required) == 0 && !present {
+			v.add(f.prefix, codeRequired, "is required in at least one language")
+		}
+		for _, lang := range required {
+			if p, ok := byLang[lang]; !ok || f.value(p) == "" {
+				v.add(f.prefix+"_"+lang, codeRequired, "is required")
+			}
+		}
+	}
+}
+
+// validate проверяет obj; при ошибках отвечает 422 со списком полей и возвращает false
+func validate(c *gin.Context, obj validatable) bool {
+	v := newValidator()
+	obj.validate(v)
+	return v.respond(c)
+}
+
+func (v *validator) respond(c *gin.Context) bool {
+	if v.err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": v.err.Error()})
+		return false
+	}
+	if v.failed() {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "validation failed", "fields": v.errs})
+		return false
+	}
+	return true
+}
+
+func (o Category) validate(v *validator) {
+	v.oneOf("entity", o.Entity, categoryEntities, false)
+	checkTranslated(v, o.Translations,
+		translatedField[*CategoryTranslation]{prefix: "name", value: func(t *CategoryTranslation) string { return t.Name }, max: maxShortText, required: true},
+		translatedField[*CategoryTranslation]{prefix: "type_name", value: func(t *CategoryTranslation) string { return t.TypeName }, max: maxShortText},
+	)
+}
+
+func (o Word) validate(v *validator) {
+	v.categoryRef("category_id", int(o.CategoryID), "word")
+	checkTranslated(v, o.Translations,
+		translatedField[*WordTranslation]{prefix: "word", value: func(t *WordTranslation) string { return t.Word }, max: maxShortText, required: true},
+		translatedField[*WordTranslation]{prefix: "transcription", value: func(t *WordTranslation) string { return t.Transcription }, max: maxShortText},
+		translatedField[*WordTranslation]{prefix: "type", value: func(t *WordTranslation) string { return t.Type }, max: maxShortText},
+	)
+}
+
+func (o Text) validate(v *validator) {
+	v.categoryRef("category_id", int(o.CategoryID), "text")
+	checkTranslated(v, o.Translations,
+		translatedField[*TextTranslation]{prefix: "title", value: func(t *TextTranslation) string { return t.Title }, max: maxTitle, required: true},
+		translatedField[*TextTranslation]{prefix: "content", value: func(t *TextTranslation) string { return t.Content }, max: maxLongText, required: true},
+		translatedField[*TextTranslation]{prefix: "transcription", value: func(t *TextTranslation) string { return t.Transcription }, max: maxLongText},
+	)
+}
+
+func (o Grammars) validate(v *validator) {
+	v.language("language", o.Language)
+	checkTranslated(v, o.Translations,
+		translatedField[*GrammarTranslation]{prefix: "title", value: func(t *GrammarTranslation) string { return t.Title }, max: maxTitle, required: true},
+		translatedField[*GrammarTranslation]{prefix: "description", value: func(t *GrammarTranslation) string { return t.Description }, max: maxLongText},
+	)
+}
+
+func (o GrammarRules) validate(v *validator) {
+	v.ref("grammar_id", "grammars", o.GrammarID, false)
+	o.validateFields(v)
+}
+
+func (o GrammarRules) validateFields(v *validator) {
+	checkTranslated(v, o.Translations,
+		translatedField[*GrammarRuleTranslation]{prefix: "rule_name", value: func(t *GrammarRuleTranslation) string { return t.Name }, max: maxTitle, required: true},
+		translatedField[*GrammarRuleTranslation]{prefix: "rule_description", value: func(t *GrammarRuleTranslation) string { return t.Description }, max: maxLongText},
+	)
+}
+
+func (o GrammarExamples) validate(v *validator) {
+	v.ref("rule_id", "grammar_rules", o.RuleID, false)
+	o.validateFields(v)
+}
+
+func (o GrammarExamples) validateFields(v *validator) {
+	checkTranslated(v, o.Translations,
+		translatedField[*GrammarExampleTranslation]{prefix: "example", value: func(t *GrammarExampleTranslation) string { return t.Example }, max: maxLongText, required: true},
+	)
+}
+
+func (o GrammarExceptions) validate(v *validator) {
+	v.ref("rule_id", "grammar_rules", o.RuleID, false)
+	o.validateFields(v)
+}
+
+func (o GrammarExceptions) validateFields(v *validator) {
+	checkTranslated(v, o.Translations,
+		translatedField[*GrammarExceptionTranslation]{prefix: "description", value: func(t *GrammarExceptionTranslation) string { return t.Description }, max: maxLongText, required: true},
+		translatedField[*GrammarExceptionTranslation]{prefix: "explanation", value: func(t *GrammarExceptionTranslation) string { return t.Explanation }, max: maxLongText},
+	)
+}
+
+// validate документа: ссылки внутри дерева проставляет сохранение, проверяются только поля
+func (d GrammarDocument) validate(v *validator) {
+	d.Grammars.validate(v)
+	for i, r := range d.Rules {
+		rv := v.at(fmt.Sprintf("rules[%d]", i))
+		r.validateFields(rv)
+		for j, e := range r.Examples {
+			e.validateFields(rv.at(fmt.Sprintf("examples[%d]", j)))
+		}
+		for j, e := range r.Exceptions {
+			e.validateFields(rv.at(fmt.Sprintf("exceptions[%d]", j)))
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// errStale — запись изменили после того, как клиент её прочитал
+var errStale = errors.New("version mismatch")
+
+func versionETag(version int) string {
+	return fmt.Sprintf(`"v%d"`, version)
+}
+
+// setETag выставляет ETag ответа. Если If-None-Match совпал, пишет 304 и возвращает true
+func setETag(c *gin.Context, etag string) bool {
+	c.Header("ETag", etag)
+	if matchETag(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return true
+	}
+	return false
+}
+
+// matchETag сравнивает список из If-Match/If-None-Match с etag (строгое сравнение, * — любой)
+func matchETag(header, etag string) bool {
+	for _, v := range strings.Split(header, ",") {
+		v = strings.TrimSpace(v)
+		if v == "*" || v == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// requireIfMatch — REQUIRE_IF_MATCH=true запрещает PUT/DELETE без If-Match
+func requireIfMatch() bool {
+	v, _ := strconv.ParseBool(os.Getenv("REQUIRE_IF_MATCH"))
+	return v
+}
+
+// checkIfMatch сверяет If-Match с текущим etag записи. При расхождении отвечает 412
+// с актуальным представлением (current вызывается только тогда) и возвращает false
+func checkIfMatch(c *gin.Context, etag string, current func() (any, error)) bool {
+	h := c.GetHeader("If-Match")
+	if h == "" {
+		if requireIfMatch() {
+			c.JSON(http.StatusPreconditionRequired, gin.H{"error": "If-Match header is required"})
+			return false
+		}
+		return true
+	}
+	if matchETag(h, etag) {
+		return true
+	}
+	respondStale(c, current)
+	return false
+}
+
+// respondStale — 412: в теле текущее состояние записи, чтобы редактор мог слить правки
+func respondStale(c *gin.Context, current func() (any, error)) {
+	obj, err := current()
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.Header("ETag", itemETag(obj))
+	c.JSON(http.StatusPreconditionFailed, gin.H{"error": errStale.Error(), "current": obj})
+}
+
+// itemETag — ETag записи по её полю Version; составные документы задают свой etag()
+func itemETag(obj any) string {
+	if v, ok := obj.(interface{ etag() string }); ok {
+		return v.etag()
+	}
+	return versionETag(int(reflect.Indirect(reflect.ValueOf(obj)).FieldByName("Version").Int()))
+}
+
+// currentItem загружает запись типа name (см. entities) для ответа 412
+func currentItem(name string, id int) func() (any, error) {
+	return func() (any, error) {
+		items, err := entities[name].load(DB.Scopes(notDeleted), []int{id})
+		if err != nil {
+			return nil, err
+		}
+		obj, ok := items[id]
+		if !ok {
+			return nil, gorm.ErrRecordNotFound
+		}
+		return obj, nil
+	}
+}
+
+func notDeleted(db *gorm.DB) *gorm.DB {
+	return db.Where("deleted_at IS NULL")
+}
+
+// bumpVersion повышает версию записи, если она всё ещё равна version; иначе errStale.
+// Вызывается в транзакции записи до сохранения, поэтому параллельная запись не пройдёт
+func bumpVersion(tx *gorm.DB, name string, id, version int) error {
+	res := tx.Table(entities[name].table).
+		Where("id = ? AND version = ? AND deleted_at IS NULL", id, version).
+		Update("version", gorm.Expr("version + 1"))
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errStale
+	}
+	return nil
+}
+
+// saveFailed отвечает на ошибку транзакции записи: errStale — 412, остальное — 500
+func saveFailed(c *gin.Context, err error, current func() (any, error)) {
+	if errors.Is(err, errStale) {
+		respondStale(c, current)
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+// lockVersion блокирует запись до конца транзакции, если её версия всё ещё равна version; иначе errStale
+func lockVersion(tx *gorm.DB, name string, id, version int) error {
+	var ids []int
+	if err := tx.Raw("SELECT id FROM "+entities[name].table+" WHERE id = ? AND version = ? AND deleted_at IS NULL FOR UPDATE",
+		id, version).Scan(&ids).Error; err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return errStale
+	}
+	return nil
+}
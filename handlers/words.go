@@ -2,41 +2,141 @@ package handlers
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 var DB *gorm.DB
 
-// Word — модель слова с FK на Category
+// Word — модель слова с FK на Category, переводы лежат в word_translations.
+// В JSON слово по-прежнему плоское: word_ru, transcription_en, audio_de и т.д.
 type Word struct {
-	ID              int    `gorm:"primaryKey;column:id"      json:"id"`
-	WordRu          string `gorm:"column:word_ru"            json:"word_ru"`
-	WordEn          string `gorm:"column:word_en"            json:"word_en"`
-	WordDe          string `gorm:"column:word_de"            json:"word_de"`
-	TranscriptionRu string `gorm:"column:transcription_ru"   json:"transcription_ru"`
-	TranscriptionEn string `gorm:"column:transcription_en"   json:"transcription_en"`
-	TranscriptionDe string `gorm:"column:transcription_de"   json:"transcription_de"`
-	AudioRu         []byte `gorm:"column:audio_ru"           json:"audio_ru"`
-	AudioEn         []byte `gorm:"column:audio_en"           json:"audio_en"`
-	AudioDe         []byte `gorm:"column:audio_de"           json:"audio_de"`
-	CategoryID      int    `gorm:"column:category_id"        json:"category_id"`
-	TypeRu          string `gorm:"column:type_ru"            json:"type_ru"`
-	TypeEn          string `gorm:"column:type_en"            json:"type_en"`
-	TypeDe          string `gorm:"column:type_de"            json:"type_de"`
-	Status          string `gorm:"column:status"             json:"status"`
+	ID           int               `gorm:"primaryKey;column:id"      json:"id"`
+	CategoryID   nullID            `gorm:"column:category_id"        json:"category_id"`
+	Status       string            `gorm:"column:status;<-:create"   json:"status"`
+	PublishAt    *time.Time        `gorm:"column:publish_at;<-:create" json:"publish_at"`
+	DeletedAt    gorm.DeletedAt    `gorm:"column:deleted_at"         json:"-"`
+	Version      int               `gorm:"column:version;default:1"  json:"-"`
+	UpdatedAt    time.Time         `gorm:"column:updated_at"         json:"updated_at"`
+	Translations []WordTranslation `gorm:"foreignKey:WordID"         json:"-"`
 }
 
 func (Word) TableName() string { return "words" }
 
+type WordTranslation struct {
+	WordID        int     `gorm:"primaryKey;column:word_id"`
+	Lang          string  `gorm:"primaryKey;column:lang"`
+	Word          string  `gorm:"column:word"`
+	Transcription string  `gorm:"column:transcription"`
+	Type          string  `gorm:"column:type"`
+	AudioID       *string `gorm:"column:audio_id"`
+	Audio         []byte  `gorm:"-"`
+}
+
+func (WordTranslation) TableName() string { return "word_translations" }
+
+func (t *WordTranslation) language() string                 { return t.Lang }
+func (t *WordTranslation) setLanguage(lang string)          { t.Lang = lang }
+func (t *WordTranslation) setOwner(id int)                  { t.WordID = id }
+func (t *WordTranslation) audioFields() (**string, *[]byte) { return &t.AudioID, &t.Audio }
+func (t *WordTranslation) jsonFields() map[string]any {
+	return map[string]any{
+		"word":          &t.Word,
+		"transcription": &t.Transcription,
+		"audio":         &t.Audio,
+		"type":          &t.Type,
+	}
+}
+
+type wordJSON Word
+
+func (w Word) MarshalJSON() ([]byte, error) {
+	return marshalTranslated(wordJSON(w), w.Translations, nil)
+}
+
+func (w *Word) UnmarshalJSON(data []byte) error {
+	return unmarshalTranslated(data, (*wordJSON)(w), &w.Translations, nil)
+}
+
+var wordList = &listSpec{
+	table: "words",
+	sorts: map[string]sortField{
+		"category_id": {expr: "COALESCE(words.category_id, 0)", kind: sortInt},
+		"status":      {expr: "words.status", kind: sortText},
+	},
+	translated: []translatedSort{
+		{prefix: "word", table: "word_translations", fk: "word_id", column: "word"},
+	},
+	filters: map[string]listFilter{
+		"category_id": intFilter("COALESCE(words.category_id, 0)"),
+		"status":      textFilter("words.status"),
+		"language":    languageFilter("words", "word_translations", "word_id", "word"),
+	},
+}
+
+var wordFields = &fieldSpec{
+	table: "words",
+	columns: map[string]string{
+		"category_id": "category_id",
+		"status":      "status",
+		"publish_at":  "publish_at",
+		"updated_at":  "updated_at",
+	},
+	trFK: "word_id",
+	translated: map[string]string{
+		"word":          "word",
+		"transcription": "transcription",
+		"type":          "type",
+		"audio":         "audio_id",
+	},
+	audio: "audio",
+}
+
 func GetWords(c *gin.Context) {
+	p, ok := parseFields(c, wordFields, false)
+	if !ok {
+		return
+	}
 	var list []Word
-	if err := DB.Find(&list).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if !findPage(c, wordList, visibleTo(c, "words", DB), p.apply, &list) {
+		return
+	}
+	if p.audio {
+		for i := range list {
+			loadAudio(list[i].Translations)
+		}
+	}
+	p.respond(c, http.StatusOK, list)
+}
+
+func GetWord(c *gin.Context) {
+	id, ok := getID(c)
+	if !ok {
+		return
+	}
+	p, ok := parseFields(c, wordFields, true)
+	if !ok {
+		return
+	}
+	var obj Word
+	if err := p.apply(visibleTo(c, "words", DB)).First(&obj, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "word not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	if setETag(c, versionETag(obj.Version)) {
 		return
 	}
-	c.JSON(http.StatusOK, list)
+	if p.audio {
+		loadAudio(obj.Translations)
+	}
+	p.respond(c, http.StatusOK, obj)
 }
 
 func CreateWord(c *gin.Context) {
@@ -44,12 +144,24 @@ func CreateWord(c *gin.Context) {
 	if !bindJSON(c, &obj) {
 		return
 	}
-	obj.AudioRu, obj.AudioEn, obj.AudioDe = genAudioForWord(&obj)
-	if err := DB.Create(&obj).Error; err != nil {
+	if !validateUnderWorkflow(c, obj, obj.Status, obj.PublishAt, statusDraft, nil) {
+		return
+	}
+	genAudioForWord(&obj)
+	err := withRevision(actorOf(c), "words", &obj.ID, actionCreate, func(tx *gorm.DB) error {
+		if err := storeTranslationAudio(tx, obj.Translations); err != nil {
+			return err
+		}
+		return tx.Create(&obj).Error
+	})
+	if err != nil {
+		releaseAudio(audioIDs(obj.Translations))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	DB.First(&obj, obj.ID)
+	DB.Preload("Translations").First(&obj, obj.ID)
+	loadAudio(obj.Translations)
+	c.Header("ETag", versionETag(obj.Version))
 	c.JSON(http.StatusCreated, obj)
 }
 
@@ -67,41 +179,57 @@ func UpdateWord(c *gin.Context) {
 		}
 		return
 	}
+	version := obj.Version
+	if !checkIfMatch(c, versionETag(version), currentItem("words", id)) {
+		return
+	}
 	var input Word
 	if !bindJSON(c, &input) {
 		return
 	}
+	if !validateUnderWorkflow(c, input, input.Status, input.PublishAt, obj.Status, obj.PublishAt) {
+		return
+	}
 	obj = Word{
-		ID:              id,
-		WordRu:          input.WordRu,
-		WordEn:          input.WordEn,
-		WordDe:          input.WordDe,
-		TranscriptionRu: input.TranscriptionRu,
-		TranscriptionEn: input.TranscriptionEn,
-		TranscriptionDe: input.TranscriptionDe,
-		CategoryID:      input.CategoryID,
-		TypeRu:          input.TypeRu,
-		TypeEn:          input.TypeEn,
-		TypeDe:          input.TypeDe,
-		Status:          input.Status,
-	}
-	obj.AudioRu, obj.AudioEn, obj.AudioDe = genAudioForWord(&obj)
-	if err := DB.Save(&obj).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ID:           id,
+		Version:      version + 1,
+		CategoryID:   input.CategoryID,
+		Translations: input.Translations,
+	}
+	genAudioForWord(&obj)
+	// клипы прежних переводов: после сохранения они освобождаются, если больше не нужны
+	var oldAudio []string
+	err := withRevision(actorOf(c), "words", &id, actionUpdate, func(tx *gorm.DB) error {
+		if err := bumpVersion(tx, "words", id, version); err != nil {
+			return err
+		}
+		if err := tx.Omit(clause.Associations).Save(&obj).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&WordTranslation{}).Where("word_id = ? AND audio_id IS NOT NULL", id).Pluck("audio_id", &oldAudio).Error; err != nil {
+			return err
+		}
+		// слово заменяется целиком: переводы, которых нет во входных данных, удаляются
+		if err := tx.Where("word_id = ?", id).Delete(&WordTranslation{}).Error; err != nil {
+			return err
+		}
+		if err := storeTranslationAudio(tx, obj.Translations); err != nil {
+			return err
+		}
+		return saveTranslations(tx, id, obj.Translations)
+	})
+	if err != nil {
+		releaseAudio(audioIDs(obj.Translations))
+		saveFailed(c, err, currentItem("words", id))
 		return
 	}
-	DB.First(&obj, id)
+	releaseAudio(oldAudio)
+	DB.Preload("Translations").First(&obj, id)
+	loadAudio(obj.Translations)
+	c.Header("ETag", versionETag(obj.Version))
 	c.JSON(http.StatusOK, obj)
 }
 
 func DeleteWord(c *gin.Context) {
-	id, ok := getID(c)
-	if !ok {
-		return
-	}
-	if err := DB.Delete(&Word{}, id).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-	c.Status(http.StatusNoContent)
+	moveToTrash(c, "words")
 }
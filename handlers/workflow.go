@@ -0,0 +1,256 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const defaultPublishInterval = time.Minute
+
+// Статусы редакционного процесса
+const (
+	statusDraft     = "draft"
+	statusInReview  = "in_review"
+	statusPublished = "published"
+	statusArchived  = "archived"
+)
+
+const actionTransition = "transition"
+
+var statuses = []string{statusDraft, statusInReview, statusPublished, statusArchived}
+
+// transitions — куда можно перейти из каждого статуса
+var transitions = map[string][]string{
+	statusDraft:     {statusInReview},
+	statusInReview:  {statusDraft, statusPublished},
+	statusPublished: {statusArchived},
+	statusArchived:  {statusDraft},
+}
+
+// workflowTypes — сущности со статусом; правила, примеры и исключения следуют за своей грамматикой
+var workflowTypes = []string{"words", "texts", "grammars"}
+
+// published — условие «опубликовано и срок публикации наступил» для строки с псевдонимом alias
+func published(alias string) string {
+	return fmt.Sprintf("(%[1]s.status = 'published' AND (%[1]s.publish_at IS NULL OR %[1]s.publish_at <= now()))", alias)
+}
+
+// publishedWhere — условие видимости записи для читателей по типам
+var publishedWhere = map[string]string{
+	"words":    published("words"),
+	"texts":    published("texts"),
+	"grammars": published("grammars"),
+	"grammar_rules": "grammar_rules.grammar_id IN (SELECT g.id FROM grammars g WHERE " +
+		published("g") + ")",
+	"grammar_examples": "grammar_examples.rule_id IN (SELECT r.id FROM grammar_rules r JOIN grammars g ON g.id = r.grammar_id WHERE " +
+		published("g") + ")",
+	"grammar_exceptions": "grammar_exceptions.rule_id IN (SELECT r.id FROM grammar_rules r JOIN grammars g ON g.id = r.grammar_id WHERE " +
+		published("g") + ")",
+}
+
+// publishedOnly оставляет в запросе к таблице name только опубликованные записи
+func publishedOnly(name string, db *gorm.DB) *gorm.DB {
+	if cond, ok := publishedWhere[name]; ok {
+		return db.Where(cond)
+	}
+	return db
+}
+
+// visibleTo — publishedOnly для читателей; редактор видит всё
+func visibleTo(c *gin.Context, name string, db *gorm.DB) *gorm.DB {
+	if isEditor(c) {
+		return db
+	}
+	return publishedOnly(name, db)
+}
+
+// validateUnderWorkflow — validate для записей со статусом. status и publish_at меняются только
+// переходами, поэтому во входных данных они допустимы лишь равными текущим (у новой записи —
+// черновик без даты): так сохраняется присланная обратно карточка, а попытка сменить статус
+// получает 422, а не молча игнорируется
+func validateUnderWorkflow(c *gin.Context, obj validatable, status string, publishAt *time.Time, curStatus string, curPublishAt *time.Time) bool {
+	v := newValidator()
+	if status != "" && status != curStatus {
+		v.add("status", codeInvalid, "use /transition to change status")
+	}
+	if publishAt != nil && (curPublishAt == nil || !publishAt.Equal(*curPublishAt)) {
+		v.add("publish_at", codeInvalid, "use /transition to schedule publishing")
+	}
+	obj.validate(v)
+	return v.respond(c)
+}
+
+// TransitionInput — тело POST /:id/transition
+type TransitionInput struct {
+	To        string     `json:"to"`
+	PublishAt *time.Time `json:"publish_at"`
+}
+
+// Transition — POST /api/<type>/:id/transition: перевод записи в статус to.
+// publish_at допустим только при публикации: до этого момента запись видна лишь редакторам
+func Transition(name string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := getID(c)
+		if !ok {
+			return
+		}
+		var in TransitionInput
+		if !bindJSON(c, &in) {
+			return
+		}
+		v := newValidator()
+		v.oneOf("to", in.To, statuses, false)
+		if in.PublishAt != nil && in.To != statusPublished {
+			v.add("publish_at", codeInvalid, "is only allowed when publishing")
+		}
+		if !v.respond(c) {
+			return
+		}
+
+		var cur struct {
+			Status  string
+			Version int
+		}
+		res := DB.Table(entities[name].table).Select("status, version").
+			Where("id = ? AND deleted_at IS NULL", id).Scan(&cur)
+		if res.Error != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": res.Error.Error()})
+			return
+		}
+		if res.RowsAffected == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "record not found"})
+			return
+		}
+		if !checkIfMatch(c, versionETag(cur.Version), currentItem(name, id)) {
+			return
+		}
+		if !allowedTransition(cur.Status, in.To) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":   fmt.Sprintf("cannot move from %s to %s", cur.Status, in.To),
+				"allowed": transitions[cur.Status],
+			})
+			return
+		}
+
+		err := withRevision(actorOf(c), name, &id, actionTransition, func(tx *gorm.DB) error {
+			if err := bumpVersion(tx, name, id, cur.Version); err != nil {
+				return err
+			}
+			if err := tx.Table(entities[name].table).Where("id = ?", id).
+				Updates(map[string]any{"status": in.To, "publish_at": in.PublishAt}).Error; err != nil {
+				return err
+			}
+			// видимость дочерних записей меняется вместе с родителем — они тоже должны попасть в синхронизацию
+			return touchChildren(tx, name, []int{id})
+		})
+		if err != nil {
+			saveFailed(c, err, currentItem(name, id))
+			return
+		}
+		obj, err := currentItem(name, id)()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.Header("ETag", itemETag(obj))
+		c.JSON(http.StatusOK, obj)
+	}
+}
+
+func allowedTransition(from, to string) bool {
+	for _, s := range transitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// touchChildren обновляет updated_at живых дочерних записей, чтобы триггер занёс их в журнал изменений
+func touchChildren(tx *gorm.DB, name string, ids []int) error {
+	for child, t := range entities {
+		if t.parent != name || !t.cascade {
+			continue
+		}
+		var childIDs []int
+		if err := tx.Table(t.table).Where(t.fk+" IN ? AND deleted_at IS NULL", ids).Pluck("id", &childIDs).Error; err != nil {
+			return err
+		}
+		if len(childIDs) == 0 {
+			continue
+		}
+		if err := tx.Table(t.table).Where("id IN ?", childIDs).Update("updated_at", gorm.Expr("now()")).Error; err != nil {
+			return err
+		}
+		if err := touchChildren(tx, child, childIDs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PublishScheduled отмечает записи, у которых наступил publish_at: сама видимость
+// зависит только от времени, но без изменения строки клиенты синхронизации их не получат.
+// Запись отмечается один раз — после этого updated_at уже не меньше publish_at
+func PublishScheduled() (int, error) {
+	total := 0
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		for _, name := range workflowTypes {
+			var ids []int
+			if err := tx.Raw("UPDATE " + entities[name].table + ` SET updated_at = now()
+				WHERE status = 'published' AND publish_at <= now() AND updated_at < publish_at AND deleted_at IS NULL
+				RETURNING id`).Scan(&ids).Error; err != nil {
+				return err
+			}
+			if len(ids) == 0 {
+				continue
+			}
+			if err := touchChildren(tx, name, ids); err != nil {
+				return err
+			}
+			total += len(ids)
+		}
+		return nil
+	})
+	return total, err
+}
+
+// StartScheduledPublishing запускает фоновую проверку отложенных публикаций раз в PUBLISH_INTERVAL
+func StartScheduledPublishing() error {
+	interval, err := envDuration("PUBLISH_INTERVAL", defaultPublishInterval)
+	if err != nil {
+		return err
+	}
+	go func() {
+		for {
+			if n, err := PublishScheduled(); err != nil {
+				log.Printf("[publish] error: %v", err)
+			} else if n > 0 {
+				log.Printf("[publish] %d scheduled item(s) went live", n)
+			}
+			time.Sleep(interval)
+		}
+	}()
+	return nil
+}
+
+// Новые записи всегда начинаются с черновика: статус меняют только переходы
+func (w *Word) BeforeCreate(*gorm.DB) error {
+	w.Status, w.PublishAt = statusDraft, nil
+	return nil
+}
+
+func (t *Text) BeforeCreate(*gorm.DB) error {
+	t.Status, t.PublishAt = statusDraft, nil
+	return nil
+}
+
+func (g *Grammars) BeforeCreate(*gorm.DB) error {
+	g.Status, g.PublishAt = statusDraft, nil
+	return nil
+}
@@ -0,0 +1,142 @@
+package languages
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"regexp"
+)
+
+// Language — описание поддерживаемого языка: от него зависят TTS, STT и валидация
+type Language struct {
+	Code        string `json:"code"`         // ISO 639-1, используется в API и в БД
+	Name        string `json:"name"`         // отображаемое название
+	EspeakVoice string `json:"espeak_voice"` // голос espeak-ng для TTS
+	Epitran     string `json:"epitran"`      // код epitran для IPA; пусто — eng_to_ipa/без IPA
+	Whisper     string `json:"whisper"`      // код языка, который возвращает Whisper
+	TextSearch  string `json:"text_search"`  // конфигурация полнотекстового поиска Postgres
+	Required    bool   `json:"required"`     // перевод на этот язык обязателен у всех записей
+}
+
+var codeRe = regexp.MustCompile(`^[a-z]{2,3}$`)
+
+// defaults используются, если файл конфигурации не найден
+var defaults = []Language{
+	{Code: "ru", Name: "Русский", EspeakVoice: "ru", Epitran: "rus-Cyrl", Whisper: "ru", TextSearch: "russian", Required: true},
+	{Code: "en", Name: "English", EspeakVoice: "en-us", Whisper: "en", TextSearch: "english"},
+	{Code: "de", Name: "Deutsch", EspeakVoice: "de", Epitran: "deu-Latn", Whisper: "de", TextSearch: "german"},
+}
+
+var (
+	list   []Language
+	byCode map[string]Language
+)
+
+func init() {
+	if err := set(defaults); err != nil {
+		panic(err)
+	}
+}
+
+// Init загружает реестр из LANGUAGES_CONFIG (по умолчанию languages.json)
+func Init() {
+	path := os.Getenv("LANGUAGES_CONFIG")
+	if path == "" {
+		path = "languages.json"
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		log.Printf("No %s found, using built-in languages", path)
+		return
+	}
+	if err != nil {
+		log.Fatalf("Failed to read languages config: %v", err)
+	}
+
+	var cfg []Language
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		log.Fatalf("Failed to parse %s: %v", path, err)
+	}
+	if err := set(cfg); err != nil {
+		log.Fatalf("Invalid languages config %s: %v", path, err)
+	}
+	log.Printf("Languages loaded from %s: %v", path, Codes())
+}
+
+func set(cfg []Language) error {
+	if len(cfg) == 0 {
+		return fmt.Errorf("no languages configured")
+	}
+	m := make(map[string]Language, len(cfg))
+	for i, l := range cfg {
+		if !codeRe.MatchString(l.Code) {
+			return fmt.Errorf("language #%d: invalid code %q", i, l.Code)
+		}
+		if _, dup := m[l.Code]; dup {
+			return fmt.Errorf("language %q declared twice", l.Code)
+		}
+		if l.Name == "" {
+			l.Name = l.Code
+		}
+		if l.EspeakVoice == "" {
+			l.EspeakVoice = l.Code
+		}
+		if l.Whisper == "" {
+			l.Whisper = l.Code
+		}
+		if l.TextSearch == "" {
+			l.TextSearch = "simple"
+		}
+		cfg[i] = l
+		m[l.Code] = l
+	}
+	list, byCode = cfg, m
+	return nil
+}
+
+// List возвращает языки в порядке конфигурации
+func List() []Language {
+	out := make([]Language, len(list))
+	copy(out, list)
+	return out
+}
+
+// Codes возвращает коды языков в порядке конфигурации
+func Codes() []string {
+	out := make([]string, len(list))
+	for i, l := range list {
+		out[i] = l.Code
+	}
+	return out
+}
+
+// Required возвращает коды обязательных языков
+func Required() []string {
+	var out []string
+	for _, l := range list {
+		if l.Required {
+			out = append(out, l.Code)
+		}
+	}
+	return out
+}
+
+func Get(code string) (Language, bool) {
+	l, ok := byCode[code]
+	return l, ok
+}
+
+func Supported(code string) bool {
+	_, ok := byCode[code]
+	return ok
+}
+
+// JSON — реестр в виде JSON для передачи Python-демонам через окружение
+func JSON() string {
+	data, _ := json.Marshal(list)
+	return string(data)
+}
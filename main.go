@@ -4,8 +4,11 @@ import (
 	"log"
 	"os"
 
+	"bd_back_for_translate_app/audio"
+	"bd_back_for_translate_app/auth"
 	"bd_back_for_translate_app/database"
 	"bd_back_for_translate_app/handlers"
+	"bd_back_for_translate_app/languages"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v4/pgxpool"
@@ -22,9 +25,49 @@ func main() {
 		log.Println("No .env file found, relying on environment variables")
 	}
 
+	languages.Init()
 	database.Init()
 	handlers.DB = database.DB
 
+	audio.Init()
+	handlers.AudioStore = audio.Storage
+
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "migrate":
+			if err := database.RunMigrateCommand(os.Args[2:]); err != nil {
+				log.Fatalf("migrate: %v", err)
+			}
+			return
+		case "user":
+			if err := handlers.RunUserCommand(os.Args[2:]); err != nil {
+				log.Fatalf("user: %v", err)
+			}
+			return
+		case "bundle":
+			path, err := handlers.BuildBundle()
+			if err != nil {
+				log.Fatalf("bundle: %v", err)
+			}
+			log.Printf("bundle written to %s", path)
+			return
+		default:
+			log.Fatalf("unknown command %q (expected: migrate, user, bundle)", os.Args[1])
+		}
+	}
+
+	auth.Init()
+
+	if n, err := database.PendingMigrations(database.DB); err != nil {
+		log.Printf("Migration status check failed: %v", err)
+	} else if n > 0 {
+		log.Printf("WARNING: %d pending migration(s), run `migrate up`", n)
+	}
+
+	if err := handlers.SyncSearchConfigs(); err != nil {
+		log.Printf("Search config sync failed: %v", err)
+	}
+
 	handlers.SttClient, err = handlers.NewSTTClient("./stt/stt_daemon.py")
 	if err != nil {
 		log.Fatalf("Ошибка запуска нейросетевого процесса: %v", err)
@@ -38,45 +81,154 @@ func main() {
 	if err := handlers.GenerateMissingWordAudio(); err != nil {
 		log.Printf("TTS batch error: %v", err)
 	}
+	if err := handlers.GenerateMissingTextAudio(); err != nil {
+		log.Printf("TTS text batch error: %v", err)
+	}
+
+	if err := handlers.StartAudioQueue(); err != nil {
+		log.Fatalf("Audio queue: %v", err)
+	}
+
+	if err := handlers.StartTrashPurge(); err != nil {
+		log.Fatalf("Trash purge: %v", err)
+	}
+
+	if err := handlers.StartChangesPrune(); err != nil {
+		log.Fatalf("Changes prune: %v", err)
+	}
+
+	if err := handlers.StartScheduledPublishing(); err != nil {
+		log.Fatalf("Scheduled publishing: %v", err)
+	}
+
+	if err := handlers.InitReviews(); err != nil {
+		log.Fatalf("Reviews: %v", err)
+	}
 
 	router := gin.Default()
 
-	router.POST("/api/upload/data", handlers.UploadDataHandler)
-
-	router.GET("/api/categories", handlers.GetCategories)
-	router.POST("/api/categories", handlers.CreateCategory)
-	router.PUT("/api/categories/:id", handlers.UpdateCategory)
-	router.DELETE("/api/categories/:id", handlers.DeleteCategory)
-
-	router.GET("/api/words", handlers.GetWords)
-	router.POST("/api/words", handlers.CreateWord)
-	router.PUT("/api/words/:id", handlers.UpdateWord)
-	router.DELETE("/api/words/:id", handlers.DeleteWord)
-
-	router.GET("/api/texts", handlers.GetTexts)
-	router.POST("/api/texts", handlers.CreateText)
-	router.PUT("/api/texts/:id", handlers.UpdateText)
-	router.DELETE("/api/texts/:id", handlers.DeleteText)
-
-	router.GET("/api/grammars", handlers.GetGrammars)
-	router.POST("/api/grammars", handlers.CreateGrammars)
-	router.PUT("/api/grammars/:id", handlers.UpdateGrammars)
-	router.DELETE("/api/grammars/:id", handlers.DeleteGrammars)
-
-	router.GET("/api/grammar/rules", handlers.GetGrammarRules)
-	router.POST("/api/grammar/rules", handlers.CreateGrammarRules)
-	router.PUT("/api/grammar/rules/:id", handlers.UpdateGrammarRules)
-	router.DELETE("/api/grammar/rules/:id", handlers.DeleteGrammarRules)
-
-	router.GET("/api/grammar/examples", handlers.GetGrammarExamples)
-	router.POST("/api/grammar/examples", handlers.CreateGrammarExamples)
-	router.PUT("/api/grammar/examples/:id", handlers.UpdateGrammarExamples)
-	router.DELETE("/api/grammar/examples/:id", handlers.DeleteGrammarExamples)
-
-	router.GET("/api/grammar/exceptions", handlers.GetGrammarExceptions)
-	router.POST("/api/grammar/exceptions", handlers.CreateGrammarExceptions)
-	router.PUT("/api/grammar/exceptions/:id", handlers.UpdateGrammarExceptions)
-	router.DELETE("/api/grammar/exceptions/:id", handlers.DeleteGrammarExceptions)
+	router.POST("/api/auth/register", handlers.Register)
+	router.POST("/api/auth/login", handlers.Login)
+	router.POST("/api/auth/refresh", handlers.RefreshTokens)
+	router.POST("/api/auth/logout", handlers.Logout)
+	router.GET("/api/languages", handlers.GetLanguages)
+
+	// остальное — только с токеном пользователя или API-ключом; группы — по требуемому праву
+	api := router.Group("", handlers.Authenticate)
+	readers := api.Group("", handlers.Require(auth.ScopeRead))
+	categoryEditors := api.Group("", handlers.Require(auth.ScopeCategoriesWrite))
+	wordEditors := api.Group("", handlers.Require(auth.ScopeWordsWrite))
+	textEditors := api.Group("", handlers.Require(auth.ScopeTextsWrite))
+	grammarEditors := api.Group("", handlers.Require(auth.ScopeGrammarsWrite))
+	trashEditors := api.Group("", handlers.Require(auth.ScopeTrashWrite))
+	audioEditors := api.Group("", handlers.Require(auth.ScopeAudioRegenerate))
+	admins := api.Group("", handlers.Require(auth.ScopeAdmin))
+
+	api.GET("/api/auth/me", handlers.RequireUser, handlers.GetMe)
+	admins.GET("/api/users", handlers.GetUsers)
+	admins.PUT("/api/users/:id/role", handlers.UpdateUserRole)
+	admins.GET("/api/api-keys", handlers.GetAPIKeys)
+	admins.POST("/api/api-keys", handlers.CreateAPIKey)
+	admins.DELETE("/api/api-keys/:id", handlers.RevokeAPIKey)
+
+	readers.POST("/api/upload/data", handlers.UploadDataHandler)
+
+	readers.GET("/api/reviews/due", handlers.RequireUser, handlers.GetDueReviews)
+	readers.POST("/api/reviews", handlers.RequireUser, handlers.PostReview)
+	readers.GET("/api/progress", handlers.RequireUser, handlers.GetProgress)
+	readers.POST("/api/progress", handlers.RequireUser, handlers.PostProgress)
+	readers.GET("/api/progress/summary", handlers.RequireUser, handlers.GetProgressSummary)
+	readers.GET("/api/progress/categories", handlers.RequireUser, handlers.GetCategoryProgress)
+	readers.GET("/api/progress/languages", handlers.RequireUser, handlers.GetLanguageProgress)
+	readers.GET("/api/progress/daily", handlers.RequireUser, handlers.GetDailyProgress)
+
+	readers.GET("/api/quizzes/vocabulary", handlers.GetVocabularyQuiz)
+	readers.POST("/api/quizzes/vocabulary", handlers.GradeVocabularyQuiz)
+
+	readers.GET("/api/search", handlers.Search)
+
+	trashEditors.GET("/api/trash", handlers.GetTrash)
+	trashEditors.POST("/api/trash/:type/:id/restore", handlers.RestoreTrash)
+	admins.GET("/api/admin/orphans", handlers.GetOrphans)
+	wordEditors.POST("/api/import/words", handlers.ImportWords)
+	textEditors.POST("/api/import/texts", handlers.ImportTexts)
+	readers.GET("/api/bundles/latest", handlers.GetLatestBundle)
+	readers.GET("/api/sync", handlers.GetSync)
+
+	readers.GET("/api/categories", handlers.GetCategories)
+	readers.GET("/api/categories/:id", handlers.GetCategory)
+	categoryEditors.POST("/api/categories", handlers.CreateCategory)
+	categoryEditors.PUT("/api/categories/:id", handlers.UpdateCategory)
+	categoryEditors.DELETE("/api/categories/:id", handlers.DeleteCategory)
+	readers.GET("/api/categories/:id/export.apkg", handlers.ExportCategoryAnki)
+	categoryEditors.GET("/api/categories/:id/history", handlers.GetHistory("categories"))
+	categoryEditors.GET("/api/categories/:id/diff", handlers.GetRevisionDiff("categories"))
+	categoryEditors.POST("/api/categories/:id/revert/:rev", handlers.RevertRevision("categories"))
+
+	readers.GET("/api/words", handlers.GetWords)
+	readers.GET("/api/words/:id", handlers.GetWord)
+	readers.GET("/api/words/lookup", handlers.LookupWords)
+	wordEditors.POST("/api/words", handlers.CreateWord)
+	wordEditors.PUT("/api/words/:id", handlers.UpdateWord)
+	wordEditors.DELETE("/api/words/:id", handlers.DeleteWord)
+	wordEditors.GET("/api/words/:id/history", handlers.GetHistory("words"))
+	wordEditors.GET("/api/words/:id/diff", handlers.GetRevisionDiff("words"))
+	wordEditors.POST("/api/words/:id/revert/:rev", handlers.RevertRevision("words"))
+	wordEditors.POST("/api/words/:id/transition", handlers.Transition("words"))
+	readers.GET("/api/words/:id/audio/:lang", handlers.GetWordAudio)
+	audioEditors.POST("/api/words/:id/audio/regenerate", handlers.RegenerateAudio("words"))
+
+	readers.GET("/api/texts", handlers.GetTexts)
+	readers.GET("/api/texts/:id", handlers.GetText)
+	textEditors.POST("/api/texts", handlers.CreateText)
+	textEditors.PUT("/api/texts/:id", handlers.UpdateText)
+	textEditors.DELETE("/api/texts/:id", handlers.DeleteText)
+	textEditors.GET("/api/texts/:id/history", handlers.GetHistory("texts"))
+	textEditors.GET("/api/texts/:id/diff", handlers.GetRevisionDiff("texts"))
+	textEditors.POST("/api/texts/:id/revert/:rev", handlers.RevertRevision("texts"))
+	textEditors.POST("/api/texts/:id/transition", handlers.Transition("texts"))
+	readers.GET("/api/texts/:id/audio/:lang", handlers.GetTextAudio)
+	audioEditors.POST("/api/texts/:id/audio/regenerate", handlers.RegenerateAudio("texts"))
+
+	readers.GET("/api/grammars", handlers.GetGrammars)
+	readers.GET("/api/grammars/:id", handlers.GetGrammar)
+	grammarEditors.POST("/api/grammars", handlers.CreateGrammars)
+	grammarEditors.PUT("/api/grammars/:id", handlers.UpdateGrammars)
+	grammarEditors.DELETE("/api/grammars/:id", handlers.DeleteGrammars)
+	grammarEditors.GET("/api/grammars/:id/history", handlers.GetHistory("grammars"))
+	grammarEditors.GET("/api/grammars/:id/diff", handlers.GetRevisionDiff("grammars"))
+	grammarEditors.POST("/api/grammars/:id/revert/:rev", handlers.RevertRevision("grammars"))
+	grammarEditors.POST("/api/grammars/:id/transition", handlers.Transition("grammars"))
+	readers.GET("/api/grammars/:id/full", handlers.GetGrammarDocument)
+	grammarEditors.POST("/api/grammars/full", handlers.CreateGrammarDocument)
+	grammarEditors.PUT("/api/grammars/:id/full", handlers.UpdateGrammarDocument)
+
+	readers.GET("/api/grammar/rules", handlers.GetGrammarRules)
+	readers.GET("/api/grammar/rules/:id", handlers.GetGrammarRule)
+	grammarEditors.POST("/api/grammar/rules", handlers.CreateGrammarRules)
+	grammarEditors.PUT("/api/grammar/rules/:id", handlers.UpdateGrammarRules)
+	grammarEditors.DELETE("/api/grammar/rules/:id", handlers.DeleteGrammarRules)
+	grammarEditors.GET("/api/grammar/rules/:id/history", handlers.GetHistory("grammar_rules"))
+	grammarEditors.GET("/api/grammar/rules/:id/diff", handlers.GetRevisionDiff("grammar_rules"))
+	grammarEditors.POST("/api/grammar/rules/:id/revert/:rev", handlers.RevertRevision("grammar_rules"))
+
+	readers.GET("/api/grammar/examples", handlers.GetGrammarExamples)
+	readers.GET("/api/grammar/examples/:id", handlers.GetGrammarExample)
+	grammarEditors.POST("/api/grammar/examples", handlers.CreateGrammarExamples)
+	grammarEditors.PUT("/api/grammar/examples/:id", handlers.UpdateGrammarExamples)
+	grammarEditors.DELETE("/api/grammar/examples/:id", handlers.DeleteGrammarExamples)
+	grammarEditors.GET("/api/grammar/examples/:id/history", handlers.GetHistory("grammar_examples"))
+	grammarEditors.GET("/api/grammar/examples/:id/diff", handlers.GetRevisionDiff("grammar_examples"))
+	grammarEditors.POST("/api/grammar/examples/:id/revert/:rev", handlers.RevertRevision("grammar_examples"))
+
+	readers.GET("/api/grammar/exceptions", handlers.GetGrammarExceptions)
+	readers.GET("/api/grammar/exceptions/:id", handlers.GetGrammarException)
+	grammarEditors.POST("/api/grammar/exceptions", handlers.CreateGrammarExceptions)
+	grammarEditors.PUT("/api/grammar/exceptions/:id", handlers.UpdateGrammarExceptions)
+	grammarEditors.DELETE("/api/grammar/exceptions/:id", handlers.DeleteGrammarExceptions)
+	grammarEditors.GET("/api/grammar/exceptions/:id/history", handlers.GetHistory("grammar_exceptions"))
+	grammarEditors.GET("/api/grammar/exceptions/:id/diff", handlers.GetRevisionDiff("grammar_exceptions"))
+	grammarEditors.POST("/api/grammar/exceptions/:id/revert/:rev", handlers.RevertRevision("grammar_exceptions"))
 
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -0,0 +1,151 @@
+// Package srs — планировщик интервального повторения FSRS-4.5
+// (https://github.com/open-spaced-repetition/fsrs4anki/wiki/The-Algorithm).
+// Состояние карточки — стабильность (через сколько дней вероятность вспомнить
+// упадёт до целевой) и сложность 1..10; по ним считается следующая дата повторения
+package srs
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Rating — оценка ответа
+type Rating int
+
+const (
+	Again Rating = iota + 1 // не вспомнил
+	Hard
+	Good
+	Easy
+)
+
+func (r Rating) Valid() bool { return r >= Again && r <= Easy }
+
+// Состояния карточки
+const (
+	StateNew        = "new"
+	StateLearning   = "learning"
+	StateReview     = "review"
+	StateRelearning = "relearning"
+)
+
+// Card — состояние повторения одной карточки
+type Card struct {
+	State      string
+	Stability  float64 // в днях
+	Difficulty float64
+	Reps       int
+	Lapses     int
+	LastReview *time.Time
+	Due        time.Time
+}
+
+// Параметры кривой забывания FSRS-4.5: R(t, S) = (1 + factor·t/S)^decay
+const (
+	decay  = -0.5
+	factor = 19.0 / 81.0
+)
+
+// DefaultWeights — веса FSRS-4.5 по умолчанию, обученные на общем наборе повторений
+var DefaultWeights = [17]float64{
+	0.4872, 1.4003, 3.7145, 13.8206, 5.1618, 1.2298, 0.8975, 0.031,
+	1.6474, 0.1367, 1.0461, 2.1072, 0.0793, 0.3246, 1.587, 0.2272, 2.8755,
+}
+
+// Scheduler — параметры планировщика
+type Scheduler struct {
+	Weights   [17]float64
+	Retention float64 // целевая вероятность вспомнить к дате повторения
+	// MaxInterval — предельный интервал в днях
+	MaxInterval int
+	// RelearnDelay — через сколько показать забытую карточку ещё раз в тот же день
+	RelearnDelay time.Duration
+}
+
+func New(retention float64) (*Scheduler, error) {
+	if retention <= 0 || retention >= 1 {
+		return nil, fmt.Errorf("retention must be between 0 and 1, got %v", retention)
+	}
+	return &Scheduler{
+		Weights:      DefaultWeights,
+		Retention:    retention,
+		MaxInterval:  36500,
+		RelearnDelay: 10 * time.Minute,
+	}, nil
+}
+
+// Retrievability — вероятность вспомнить карточку в момент now
+func (s *Scheduler) Retrievability(c Card, now time.Time) float64 {
+	if c.State == StateNew || c.LastReview == nil || c.Stability <= 0 {
+		return 0
+	}
+	return math.Pow(1+factor*elapsedDays(*c.LastReview, now)/c.Stability, decay)
+}
+
+// Review применяет оценку r, поставленную в момент now, и возвращает новое состояние
+func (s *Scheduler) Review(c Card, r Rating, now time.Time) Card {
+	w := s.Weights
+	if c.State == StateNew || c.LastReview == nil {
+		c.Stability = w[r-1]
+		c.Difficulty = s.initDifficulty(r)
+	} else {
+		R := s.Retrievability(c, now)
+		if r == Again {
+			// после забывания стабильность не может вырасти
+			c.Stability = math.Min(c.Stability,
+				w[11]*math.Pow(c.Difficulty, -w[12])*(math.Pow(c.Stability+1, w[13])-1)*math.Exp(w[14]*(1-R)))
+		} else {
+			bonus := 1.0
+			if r == Hard {
+				bonus = w[15]
+			} else if r == Easy {
+				bonus = w[16]
+			}
+			c.Stability *= 1 + math.Exp(w[8])*(11-c.Difficulty)*math.Pow(c.Stability, -w[9])*
+				(math.Exp(w[10]*(1-R))-1)*bonus
+		}
+		// сложность сдвигается оценкой и понемногу возвращается к начальной сложности
+		// при ответе Good — w[4] (в FSRS-4.5, в отличие от FSRS-5, не к Easy)
+		d := c.Difficulty - w[6]*float64(r-3)
+		c.Difficulty = clamp(w[7]*w[4]+(1-w[7])*d, 1, 10)
+	}
+	c.Stability = math.Max(c.Stability, 0.01)
+
+	c.Reps++
+	reviewed := now
+	c.LastReview = &reviewed
+	switch {
+	case r == Again && (c.State == StateNew || c.State == StateLearning):
+		c.State = StateLearning
+		c.Due = now.Add(s.RelearnDelay)
+	case r == Again:
+		if c.State == StateReview {
+			c.Lapses++
+		}
+		c.State = StateRelearning
+		c.Due = now.Add(s.RelearnDelay)
+	default:
+		c.State = StateReview
+		c.Due = now.AddDate(0, 0, s.interval(c.Stability))
+	}
+	return c
+}
+
+func (s *Scheduler) initDifficulty(r Rating) float64 {
+	return clamp(s.Weights[4]-float64(r-3)*s.Weights[5], 1, 10)
+}
+
+// interval — через сколько дней вероятность вспомнить упадёт до Retention
+func (s *Scheduler) interval(stability float64) int {
+	days := int(math.Round(stability / factor * (math.Pow(s.Retention, 1/decay) - 1)))
+	return max(1, min(days, s.MaxInterval))
+}
+
+func elapsedDays(from, to time.Time) float64 {
+	return math.Max(0, to.Sub(from).Hours()/24)
+}
+
+func clamp(v, lo, hi float64) float64 {
+	return math.Max(lo, math.Min(hi, v))
+}
@@ -0,0 +1,120 @@
+package srs
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+var start = time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+
+func newScheduler(t *testing.T) *Scheduler {
+	t.Helper()
+	s, err := New(0.9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func near(a, b float64) bool { return math.Abs(a-b) < 1e-3 }
+
+func TestNewRejectsRetention(t *testing.T) {
+	for _, r := range []float64{0, 1, -0.5, 1.2} {
+		if _, err := New(r); err == nil {
+			t.Errorf("New(%v): expected error", r)
+		}
+	}
+}
+
+// Первый ответ: стабильность — w[r-1], сложность — D0(r) = w4 - (r-3)·w5
+func TestFirstReview(t *testing.T) {
+	s := newScheduler(t)
+	cases := []struct {
+		rating     Rating
+		stability  float64
+		difficulty float64
+		state      string
+		due        time.Duration
+	}{
+		{Again, 0.4872, 7.6214, StateLearning, 10 * time.Minute},
+		{Hard, 1.4003, 6.3916, StateReview, 24 * time.Hour},
+		{Good, 3.7145, 5.1618, StateReview, 4 * 24 * time.Hour},
+		{Easy, 13.8206, 3.932, StateReview, 14 * 24 * time.Hour},
+	}
+	for _, tc := range cases {
+		c := s.Review(Card{State: StateNew}, tc.rating, start)
+		if !near(c.Stability, tc.stability) || !near(c.Difficulty, tc.difficulty) {
+			t.Errorf("rating %d: S=%.4f D=%.4f, want S=%.4f D=%.4f", tc.rating, c.Stability, c.Difficulty, tc.stability, tc.difficulty)
+		}
+		if c.State != tc.state || c.Due.Sub(start) != tc.due {
+			t.Errorf("rating %d: state %s due +%v, want %s +%v", tc.rating, c.State, c.Due.Sub(start), tc.state, tc.due)
+		}
+		if c.Reps != 1 || c.Lapses != 0 || c.LastReview == nil || !c.LastReview.Equal(start) {
+			t.Errorf("rating %d: reps=%d lapses=%d last=%v", tc.rating, c.Reps, c.Lapses, c.LastReview)
+		}
+	}
+}
+
+// При целевой вероятности 0.9 интервал FSRS-4.5 равен стабильности
+func TestIntervalAtDefaultRetention(t *testing.T) {
+	s := newScheduler(t)
+	cases := map[float64]int{0.4872: 1, 1.4003: 1, 3.7145: 4, 13.8206: 14, 14.8081: 15, 100: 100, 1e6: 36500}
+	for stability, want := range cases {
+		if got := s.interval(stability); got != want {
+			t.Errorf("interval(%v) = %d, want %d", stability, got, want)
+		}
+	}
+	if r := s.Retrievability(Card{State: StateReview, Stability: 10, LastReview: &start}, start.AddDate(0, 0, 10)); !near(r, 0.9) {
+		t.Errorf("retrievability after S days = %.4f, want 0.9", r)
+	}
+}
+
+func TestSecondReviewGood(t *testing.T) {
+	s := newScheduler(t)
+	c := s.Review(Card{State: StateNew}, Good, start)
+	c = s.Review(c, Good, c.Due)
+	// после Good сложность стоит на месте: D0(Good) = w4 и есть цель возврата
+	if !near(c.Stability, 14.8081) || !near(c.Difficulty, 5.1618) {
+		t.Errorf("S=%.4f D=%.4f, want S=14.8081 D=5.1618", c.Stability, c.Difficulty)
+	}
+	if c.Due.Sub(start) != (4+15)*24*time.Hour || c.Reps != 2 {
+		t.Errorf("due +%v reps=%d", c.Due.Sub(start), c.Reps)
+	}
+}
+
+func TestLapseAndRelearning(t *testing.T) {
+	s := newScheduler(t)
+	c := s.Review(Card{State: StateNew}, Good, start)
+	c = s.Review(c, Good, c.Due)
+	now := c.Due
+	c = s.Review(c, Again, now)
+	if c.State != StateRelearning || c.Lapses != 1 || c.Due.Sub(now) != 10*time.Minute {
+		t.Fatalf("after Again: state %s lapses %d due +%v", c.State, c.Lapses, c.Due.Sub(now))
+	}
+	if !near(c.Stability, 3.1493) || !near(c.Difficulty, 6.9012) {
+		t.Errorf("after Again: S=%.4f D=%.4f, want S=3.1493 D=6.9012", c.Stability, c.Difficulty)
+	}
+
+	// повторное забывание во время переобучения — не новый провал
+	now = c.Due
+	c = s.Review(c, Again, now)
+	if c.State != StateRelearning || c.Lapses != 1 {
+		t.Errorf("Again while relearning: state %s lapses %d", c.State, c.Lapses)
+	}
+
+	now = c.Due
+	c = s.Review(c, Good, now)
+	if c.State != StateReview || c.Lapses != 1 || c.Due.Sub(now) < 24*time.Hour {
+		t.Errorf("Good after relearning: state %s lapses %d due +%v", c.State, c.Lapses, c.Due.Sub(now))
+	}
+}
+
+func TestLearningAgainIsNotLapse(t *testing.T) {
+	s := newScheduler(t)
+	c := s.Review(Card{State: StateNew}, Again, start)
+	c = s.Review(c, Again, c.Due)
+	if c.State != StateLearning || c.Lapses != 0 || c.Reps != 2 {
+		t.Errorf("state %s lapses %d reps %d", c.State, c.Lapses, c.Reps)
+	}
+}
@@ -14,9 +14,13 @@ warnings.filterwarnings("ignore")
 sys.stderr.write("Загрузка моделей...\n")
 sys.stderr.flush()
 model = whisper.load_model("small")
+# реестр языков передаётся из Go (languages.json) через окружение
+LANGUAGES = json.loads(os.getenv("LANGUAGES_JSON") or "[]")
+# код Whisper -> код языка приложения
+whisper_codes = {(l.get("whisper") or l["code"]): l["code"] for l in LANGUAGES}
 epi_models = {
-    'ru': epitran.Epitran('rus-Cyrl'),
-    'de': epitran.Epitran('deu-Latn')
+    l["code"]: epitran.Epitran(l["epitran"])
+    for l in LANGUAGES if l.get("epitran")
 }
 sys.stderr.write("Модели загружены.\n")
 sys.stderr.flush()
@@ -66,17 +70,20 @@ def manual_transliteration(text):
 def audio_to_ipa(audio_file):
     result = model.transcribe(audio_file)
     text = result['text'].strip()
-    lang = result['language']
-    if lang == 'en':
+    lang = whisper_codes.get(result['language'])
+    if lang is None:
+        ipa_trans = "Language not supported for IPA transcription."
+    elif lang in epi_models:
+        ipa_trans = epi_models[lang].transliterate(text)
+    elif result['language'] == 'en':
+        # для английского epitran требует flite, поэтому используем eng_to_ipa
         eng_result = engipa.convert(text)
         if eng_result.endswith('*'):
             eng_result = manual_transliteration(text)
         ipa_trans = eng_result
-    elif lang in epi_models:
-        ipa_trans = epi_models[lang].transliterate(text)
     else:
         ipa_trans = "Language not supported for IPA transcription."
-    return {"text": text, "ipa_transcription": ipa_trans, "language": lang}
+    return {"text": text, "ipa_transcription": ipa_trans, "language": lang or result['language']}
 
 def process_request(req_json):
     audio_path = req_json.get("audio_path")
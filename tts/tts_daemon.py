@@ -7,14 +7,15 @@ def log(msg: str):
 
 DB_URL = os.getenv("DATABASE_URL")
 
-VOICE_MAP = {
-    "en": "en-us",
-    "de": "de",
-    "ru": "ru",
-    # добавляйте при необходимости: "fr": "fr-fr", …
-}
+# реестр языков передаётся из Go (languages.json) через окружение
+LANGUAGES = json.loads(os.getenv("LANGUAGES_JSON") or "[]")
+VOICE_MAP = {l["code"]: l.get("espeak_voice") or l["code"] for l in LANGUAGES}
+log(f"voices: {VOICE_MAP}")
+
 def speak_to_wav(text: str, lang: str) -> bytes:
-    voice = VOICE_MAP.get(lang, lang)
+    voice = VOICE_MAP.get(lang)
+    if not voice:
+        raise ValueError(f"unsupported language: {lang}")
     tmp = tempfile.NamedTemporaryFile(delete=False, suffix=".wav")
     tmp.close()
     try: